@@ -0,0 +1,150 @@
+// Package alerts watches market data and the connection for user defined
+// conditions, off the render thread, and reports each alert that fires to
+// the UI, the log and optionally a webhook.
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Metric is what an alert watches
+type Metric string
+
+const (
+	// MetricPrice is the last traded price
+	MetricPrice Metric = "price"
+	// MetricSpread is the top of book spread in basis points of the mid
+	MetricSpread Metric = "spread"
+	// MetricImbalance is (bid size - ask size) / total of the best bid
+	// and ask only, from -1 to 1. It is not the depth imbalance the order
+	// book panel shows, which only exists for the focused instrument.
+	MetricImbalance Metric = "imbalance"
+	// MetricFunding is the 8 hour funding rate of a perpetual in percent
+	MetricFunding Metric = "funding"
+	// MetricVolumeSpike is the volume of the last minute as a multiple of
+	// the average minute of the last 24 hours
+	MetricVolumeSpike Metric = "volume_spike"
+	// MetricConnection is the connection to Deribit, the alert fires when
+	// it is lost and ignores instrument and threshold
+	MetricConnection Metric = "connection"
+)
+
+// Metrics lists every metric, in the order the UI offers them
+var Metrics = []Metric{MetricPrice, MetricSpread, MetricImbalance, MetricFunding, MetricVolumeSpike, MetricConnection}
+
+// Level reports whether the metric is a state worth alerting on as soon
+// as it is past the threshold, rather than on crossing it
+func (m Metric) Level() bool {
+	switch m {
+	case MetricSpread, MetricImbalance, MetricFunding, MetricConnection:
+		return true
+	}
+	return false
+}
+
+// Label names the metric with its unit
+func (m Metric) Label() string {
+	switch m {
+	case MetricPrice:
+		return "Price"
+	case MetricSpread:
+		return "Spread (bps)"
+	case MetricImbalance:
+		return "Top-of-book imbalance"
+	case MetricFunding:
+		return "Funding 8h (%)"
+	case MetricVolumeSpike:
+		return "Volume spike (x)"
+	case MetricConnection:
+		return "Connection lost"
+	}
+	return string(m)
+}
+
+// Mode is what happens after an alert fires
+type Mode string
+
+const (
+	// ModeOnce disarms the alert until it is re-armed by hand
+	ModeOnce Mode = "once"
+	// ModeRearm arms the alert again once its condition clears
+	ModeRearm Mode = "rearm"
+)
+
+// Alert is a condition on a metric of an instrument. Alerts on a level,
+// like the spread or the connection, fire as soon as the metric is past
+// the threshold. The others fire when the metric crosses it: they have to
+// see their condition false before they can fire, so one created past its
+// threshold waits for the next cross. After firing every alert waits for
+// its condition to clear before it can fire again.
+type Alert struct {
+	ID         int    `json:"id"`
+	Instrument string `json:"instrument,omitempty"`
+	Metric     Metric `json:"metric"`
+	// Above fires when the metric rises above Threshold, otherwise when it
+	// falls below
+	Above     bool    `json:"above"`
+	Threshold float64 `json:"threshold"`
+	Mode      Mode    `json:"mode"`
+	// Armed alerts can fire, FiredAt is when one last did
+	Armed   bool      `json:"armed"`
+	FiredAt time.Time `json:"fired_at,omitzero"`
+}
+
+// Describe is the alert in words, as in "BTC-PERPETUAL price above 70000"
+func (a Alert) Describe() string {
+	if a.Metric == MetricConnection {
+		return "Connection lost"
+	}
+	direction := "below"
+	if a.Above {
+		direction = "above"
+	}
+	return fmt.Sprintf("%s %s %s %g", a.Instrument, a.Metric.Label(), direction, a.Threshold)
+}
+
+// met reports whether value is past the threshold
+func (a Alert) met(value float64) bool {
+	if a.Above {
+		return value > a.Threshold
+	}
+	return value < a.Threshold
+}
+
+// Notification is an alert that fired
+type Notification struct {
+	AlertID    int       `json:"alert_id"`
+	Instrument string    `json:"instrument,omitempty"`
+	Metric     Metric    `json:"metric"`
+	Above      bool      `json:"above"`
+	Threshold  float64   `json:"threshold"`
+	Value      float64   `json:"value"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+}
+
+// tickerValue reads a metric off a ticker, false when the ticker lacks it
+func tickerValue(metric Metric, ticker *models.Ticker) (float64, bool) {
+	switch metric {
+	case MetricPrice:
+		return ticker.LastPrice, ticker.LastPrice > 0
+	case MetricSpread:
+		bid, ask := ticker.BestBidPrice, ticker.BestAskPrice
+		if bid <= 0 || ask <= 0 {
+			return 0, false
+		}
+		return (ask - bid) / ((ask + bid) / 2) * 10_000, true
+	case MetricImbalance:
+		total := ticker.BestBidAmount + ticker.BestAskAmount
+		if total == 0 {
+			return 0, false
+		}
+		return (ticker.BestBidAmount - ticker.BestAskAmount) / total, true
+	case MetricFunding:
+		return ticker.Funding8h * 100, true
+	}
+	return 0, false
+}
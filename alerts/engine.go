@@ -0,0 +1,505 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/settings"
+)
+
+const (
+	alertsFile = "alerts.json"
+	// eventQueueSize bounds the tickers waiting for evaluation; when the
+	// engine falls behind, tickers are dropped rather than blocking the
+	// network goroutines that publish them. Connection changes are queued
+	// apart and never dropped.
+	eventQueueSize = 1024
+	// logSize is how many fired alerts are kept for the UI
+	logSize        = 50
+	webhookTimeout = 5 * time.Second
+	// webhookQueueSize bounds the fired alerts waiting to be posted; past
+	// it, while the webhook is slow or down, they are dropped
+	webhookQueueSize = 64
+	// spikeWindow is the span the volume of a spike is measured over
+	spikeWindow = time.Minute
+)
+
+// saved is the on-disk format
+type saved struct {
+	WebhookURL string  `json:"webhook_url"`
+	NextID     int     `json:"next_id"`
+	Alerts     []Alert `json:"alerts"`
+
+	// version orders the snapshots, so an older one never overwrites a
+	// newer one that was written first
+	version uint64
+}
+
+// event is a ticker or a connection change to evaluate alerts against
+type event struct {
+	instrument string
+	ticker     *models.Ticker
+	connected  *bool
+}
+
+// webhookPost is a fired alert to post to a webhook
+type webhookPost struct {
+	url          string
+	notification Notification
+}
+
+// volumeSample is an instrument's 24 hour volume at one time
+type volumeSample struct {
+	time   time.Time
+	volume float64
+}
+
+// Engine evaluates the alerts on its own goroutine as tickers are
+// published to the store and as the connection changes. Alerts and the
+// webhook URL are saved on every change.
+type Engine struct {
+	store  *marketdata.Store
+	events chan event
+	done   chan struct{}
+	http   *http.Client
+	// webhooks are posted in order by a single worker
+	webhooks chan webhookPost
+
+	// watched is the set of instruments with alerts, read on every
+	// publish to skip the tickers no alert is about
+	watched atomic.Pointer[map[string]bool]
+
+	// connections are the connection changes not yet evaluated, in
+	// order; connectionsReady wakes the worker when one is added
+	connectionsMu    sync.Mutex
+	connections      []bool
+	connectionsReady chan struct{}
+
+	// saveMu serializes the writes of alerts.json, saveVersion is the
+	// version of the last snapshot written
+	saveMu      sync.Mutex
+	saveVersion uint64
+
+	mu         sync.Mutex
+	version    uint64
+	alerts     []Alert
+	nextID     int
+	webhookURL string
+	// ready holds the alerts that may fire: those on a level once armed,
+	// the others once they saw their condition false, and any alert that
+	// fired once its condition cleared
+	ready map[int]bool
+	// volumes are recent samples per instrument, for volume spikes
+	volumes map[string][]volumeSample
+	pending []Notification
+	log     []Notification
+
+	onInstruments func(instruments []string)
+}
+
+func NewEngine(store *marketdata.Store) *Engine {
+	return &Engine{
+		store:            store,
+		events:           make(chan event, eventQueueSize),
+		done:             make(chan struct{}),
+		http:             &http.Client{Timeout: webhookTimeout},
+		webhooks:         make(chan webhookPost, webhookQueueSize),
+		connectionsReady: make(chan struct{}, 1),
+		nextID:           1,
+		ready:            make(map[int]bool),
+		volumes:          make(map[string][]volumeSample),
+	}
+}
+
+// Load restores the alerts and webhook URL saved by the last run
+func (e *Engine) Load() error {
+	var state saved
+	if err := settings.Load(alertsFile, &state); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	e.mu.Lock()
+	e.alerts = state.Alerts
+	e.nextID = max(state.NextID, 1)
+	e.webhookURL = state.WebhookURL
+	clear(e.ready)
+	for i := range e.alerts {
+		if e.alerts[i].Armed {
+			e.arm(&e.alerts[i])
+		}
+	}
+	e.mu.Unlock()
+
+	e.instrumentsChanged()
+	return nil
+}
+
+// arm arms an alert, the caller holds mu. Alerts on a level may fire on
+// their first evaluation, the others wait for their condition to be false
+// first.
+func (e *Engine) arm(alert *Alert) {
+	alert.Armed = true
+	if alert.Metric.Level() {
+		e.ready[alert.ID] = true
+	} else {
+		delete(e.ready, alert.ID)
+	}
+}
+
+// snapshot copies the state to save, the caller holds mu. Save it with
+// save after unlocking, so the file write never holds up evaluation.
+func (e *Engine) snapshot() saved {
+	e.version++
+	return saved{
+		WebhookURL: e.webhookURL,
+		NextID:     e.nextID,
+		Alerts:     slices.Clone(e.alerts),
+		version:    e.version,
+	}
+}
+
+// save writes a snapshot of the alerts, unless a newer one was written
+func (e *Engine) save(state saved) {
+	e.saveMu.Lock()
+	defer e.saveMu.Unlock()
+	if state.version <= e.saveVersion {
+		return
+	}
+	e.saveVersion = state.version
+	if err := settings.Save(alertsFile, state); err != nil {
+		log.Printf("Failed to save alerts: %v", err)
+	}
+}
+
+// SetOnInstrumentsHandler sets the callback for when the instruments with
+// alerts change, used to follow their tickers
+func (e *Engine) SetOnInstrumentsHandler(handler func([]string)) {
+	e.onInstruments = handler
+	e.instrumentsChanged()
+}
+
+func (e *Engine) instrumentsChanged() {
+	var instruments []string
+	watched := make(map[string]bool)
+	for _, alert := range e.Alerts() {
+		if alert.Instrument != "" && !watched[alert.Instrument] {
+			instruments = append(instruments, alert.Instrument)
+			watched[alert.Instrument] = true
+		}
+	}
+	e.watched.Store(&watched)
+
+	if e.onInstruments != nil {
+		e.onInstruments(instruments)
+	}
+}
+
+// Start evaluates the alerts against every ticker published from now on
+// until Stop
+func (e *Engine) Start() {
+	e.store.OnPublish(func(instrument string, kind marketdata.Kind, snapshot marketdata.Snapshot) {
+		if kind != marketdata.KindTicker {
+			return
+		}
+		if watched := e.watched.Load(); watched == nil || !(*watched)[instrument] {
+			return
+		}
+		ticker, _ := snapshot.Value.(*models.Ticker)
+		select {
+		case e.events <- event{instrument: instrument, ticker: ticker}:
+		default:
+		}
+	})
+
+	go func() {
+		for {
+			select {
+			case <-e.done:
+				return
+			case post := <-e.webhooks:
+				e.post(post.url, post.notification)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-e.connectionsReady:
+				e.connectionsMu.Lock()
+				connections := e.connections
+				e.connections = nil
+				e.connectionsMu.Unlock()
+				for _, connected := range connections {
+					e.evaluate(event{connected: &connected})
+				}
+			case ev := <-e.events:
+				e.evaluate(ev)
+			}
+		}
+	}()
+}
+
+func (e *Engine) Stop() {
+	close(e.done)
+}
+
+// SetConnected reports the connection state, for connection alerts. Call
+// it from a client state listener.
+func (e *Engine) SetConnected(connected bool) {
+	e.connectionsMu.Lock()
+	e.connections = append(e.connections, connected)
+	e.connectionsMu.Unlock()
+
+	select {
+	case e.connectionsReady <- struct{}{}:
+	default:
+	}
+}
+
+// Add arms a new alert and returns its ID
+func (e *Engine) Add(alert Alert) int {
+	e.mu.Lock()
+	alert.ID = e.nextID
+	alert.FiredAt = time.Time{}
+	e.arm(&alert)
+	e.nextID++
+	e.alerts = append(e.alerts, alert)
+	state := e.snapshot()
+	e.mu.Unlock()
+
+	e.save(state)
+
+	e.instrumentsChanged()
+	return alert.ID
+}
+
+func (e *Engine) Remove(id int) {
+	e.mu.Lock()
+	e.alerts = slices.DeleteFunc(e.alerts, func(alert Alert) bool {
+		return alert.ID == id
+	})
+	delete(e.ready, id)
+	state := e.snapshot()
+	e.mu.Unlock()
+
+	e.save(state)
+
+	e.instrumentsChanged()
+}
+
+// Rearm arms an alert again, it fires on the next cross, or for a level
+// on the next evaluation past it
+func (e *Engine) Rearm(id int) {
+	e.mu.Lock()
+	for i := range e.alerts {
+		if e.alerts[i].ID == id {
+			e.arm(&e.alerts[i])
+		}
+	}
+	state := e.snapshot()
+	e.mu.Unlock()
+
+	e.save(state)
+}
+
+// Alerts returns a copy of every alert
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.alerts)
+}
+
+func (e *Engine) WebhookURL() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.webhookURL
+}
+
+// SetWebhookURL sets where fired alerts are POSTed, empty for nowhere
+func (e *Engine) SetWebhookURL(url string) {
+	e.mu.Lock()
+	e.webhookURL = url
+	state := e.snapshot()
+	e.mu.Unlock()
+
+	e.save(state)
+}
+
+// TakeNotifications returns the alerts fired since the last call, for
+// the UI to show
+func (e *Engine) TakeNotifications() []Notification {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pending := e.pending
+	e.pending = nil
+	return pending
+}
+
+// Log returns the latest fired alerts, newest first
+func (e *Engine) Log() []Notification {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.log)
+}
+
+// volumeSpike records the ticker's 24 hour volume and returns the volume
+// of the last minute as a multiple of the 24 hour average minute. The 24
+// hour volume is rolling, so the difference also loses the volume of the
+// minute 24 hours ago; against a spike that is noise.
+func (e *Engine) volumeSpike(instrument string, ticker *models.Ticker, now time.Time) (float64, bool) {
+	samples := append(e.volumes[instrument], volumeSample{now, ticker.Stats.Volume})
+	// Keep one sample older than the window to measure from
+	for len(samples) > 2 && now.Sub(samples[1].time) >= spikeWindow {
+		samples = samples[1:]
+	}
+	e.volumes[instrument] = samples
+
+	oldest := samples[0]
+	elapsed := now.Sub(oldest.time)
+	average := ticker.Stats.Volume / (24 * 60)
+	if elapsed < spikeWindow || average <= 0 {
+		return 0, false
+	}
+	perMinute := max(0, ticker.Stats.Volume-oldest.volume) / elapsed.Minutes()
+	return perMinute / average, true
+}
+
+func (e *Engine) evaluate(ev event) {
+	now := time.Now()
+	var fired []Notification
+
+	e.mu.Lock()
+	// Volume is only sampled for instruments with a spike alert
+	spike, hasSpike := 0.0, false
+	if ev.ticker != nil {
+		if slices.ContainsFunc(e.alerts, func(alert Alert) bool {
+			return alert.Metric == MetricVolumeSpike && alert.Instrument == ev.instrument
+		}) {
+			spike, hasSpike = e.volumeSpike(ev.instrument, ev.ticker, now)
+		} else {
+			delete(e.volumes, ev.instrument)
+		}
+	}
+	for i := range e.alerts {
+		alert := &e.alerts[i]
+		if !alert.Armed {
+			continue
+		}
+
+		var value float64
+		var met bool
+		switch {
+		case alert.Metric == MetricConnection:
+			if ev.connected == nil {
+				continue
+			}
+			if !*ev.connected {
+				value, met = 1, true
+			}
+		case ev.ticker == nil || alert.Instrument != ev.instrument:
+			continue
+		case alert.Metric == MetricVolumeSpike:
+			if !hasSpike {
+				continue
+			}
+			value, met = spike, alert.met(spike)
+		default:
+			var ok bool
+			if value, ok = tickerValue(alert.Metric, ev.ticker); !ok {
+				continue
+			}
+			met = alert.met(value)
+		}
+
+		if !met {
+			e.ready[alert.ID] = true
+			continue
+		}
+		if !e.ready[alert.ID] {
+			continue
+		}
+
+		// Fire, then wait for the condition to clear before firing again
+		delete(e.ready, alert.ID)
+		alert.FiredAt = now
+		if alert.Mode == ModeOnce {
+			alert.Armed = false
+		}
+		message := alert.Describe()
+		if alert.Metric != MetricConnection {
+			message = fmt.Sprintf("%s (now %g)", message, value)
+		}
+		fired = append(fired, Notification{
+			AlertID:    alert.ID,
+			Instrument: alert.Instrument,
+			Metric:     alert.Metric,
+			Above:      alert.Above,
+			Threshold:  alert.Threshold,
+			Value:      value,
+			Message:    message,
+			Time:       now,
+		})
+	}
+
+	webhookURL := e.webhookURL
+	var state saved
+	if len(fired) > 0 {
+		e.pending = append(e.pending, fired...)
+		for _, notification := range fired {
+			e.log = append([]Notification{notification}, e.log...)
+		}
+		e.log = e.log[:min(len(e.log), logSize)]
+		state = e.snapshot()
+	}
+	e.mu.Unlock()
+
+	if len(fired) > 0 {
+		e.save(state)
+	}
+	for _, notification := range fired {
+		log.Printf("Alert: %s", notification.Message)
+		if webhookURL == "" {
+			continue
+		}
+		select {
+		case e.webhooks <- webhookPost{webhookURL, notification}:
+		default:
+			log.Printf("Dropped alert webhook, %d waiting", len(e.webhooks))
+		}
+	}
+}
+
+// post delivers a fired alert to the webhook as JSON
+func (e *Engine) post(url string, notification Notification) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("Failed to encode alert: %v", err)
+		return
+	}
+	response, err := e.http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to post alert to webhook: %v", err)
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		log.Printf("Alert webhook returned %s", response.Status)
+	}
+}
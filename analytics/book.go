@@ -0,0 +1,184 @@
+// Package analytics derives liquidity metrics from order book snapshots
+package analytics
+
+import (
+	"math"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Config selects what Compute measures
+type Config struct {
+	// Levels is how many levels per side imbalance and weighted mid use
+	Levels int
+	// Bands are distances from mid, as fractions, to measure depth within
+	Bands []float64
+	// OrderNotional is the USD size of the market orders to estimate
+	OrderNotional float64
+}
+
+var DefaultConfig = Config{
+	Levels:        10,
+	Bands:         []float64{0.001, 0.005, 0.01, 0.02},
+	OrderNotional: 100_000,
+}
+
+// DepthBand is the size resting within Band of mid on each side
+type DepthBand struct {
+	Band float64
+	Bid  float64
+	Ask  float64
+	// BidNotional and AskNotional are the same in USD
+	BidNotional float64
+	AskNotional float64
+}
+
+// Fill estimates a market order walking one side of the book
+type Fill struct {
+	// Notional is the USD size of the order, Filled how much of it the
+	// book could take
+	Notional float64
+	Filled   float64
+	// AvgPrice is the average fill price, Slippage its distance from mid
+	// in basis points
+	AvgPrice float64
+	Slippage float64
+	// Levels is the number of levels the order reached
+	Levels int
+}
+
+// Complete reports whether the book was deep enough for the whole order
+func (f Fill) Complete() bool {
+	return f.Filled >= f.Notional*(1-1e-9)
+}
+
+// Metrics are the analytics of one order book snapshot
+type Metrics struct {
+	Mid    float64
+	Spread float64
+	// Imbalance is (bid size - ask size) / total over the top levels, from
+	// -1 (all asks) to 1 (all bids)
+	Imbalance float64
+	// Microprice weighs the best bid and ask by the size on the opposite
+	// side, leaning towards the side likely to trade through
+	Microprice float64
+	// WeightedMid is the size weighted average price of the top levels
+	WeightedMid float64
+	Depth       []DepthBand
+	// Buy and Sell are market orders of Config.OrderNotional
+	Buy  Fill
+	Sell Fill
+}
+
+// Compute returns the metrics of book, nil if either side is empty.
+// spec converts amounts to USD and may be nil.
+func Compute(book *models.OrderBookResult, spec *models.Instrument, config Config) *Metrics {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil
+	}
+
+	bestBid, bestAsk := book.Bids[0], book.Asks[0]
+	mid := (bestBid[0] + bestAsk[0]) / 2
+	metrics := &Metrics{
+		Mid:         mid,
+		Spread:      bestAsk[0] - bestBid[0],
+		Imbalance:   Imbalance(book, config.Levels),
+		Microprice:  Microprice(book),
+		WeightedMid: WeightedMid(book, config.Levels),
+		Buy:         EstimateFill(book.Asks, mid, config.OrderNotional, spec),
+		Sell:        EstimateFill(book.Bids, mid, config.OrderNotional, spec),
+	}
+	for _, band := range config.Bands {
+		metrics.Depth = append(metrics.Depth, DepthWithin(book, band, spec))
+	}
+	return metrics
+}
+
+// Imbalance compares the size of the top levels levels of each side
+func Imbalance(book *models.OrderBookResult, levels int) float64 {
+	bids, asks := 0.0, 0.0
+	for _, level := range book.Bids[:min(levels, len(book.Bids))] {
+		bids += level[1]
+	}
+	for _, level := range book.Asks[:min(levels, len(book.Asks))] {
+		asks += level[1]
+	}
+	if bids+asks == 0 {
+		return 0
+	}
+	return (bids - asks) / (bids + asks)
+}
+
+func Microprice(book *models.OrderBookResult) float64 {
+	bid, ask := book.Bids[0], book.Asks[0]
+	if bid[1]+ask[1] == 0 {
+		return (bid[0] + ask[0]) / 2
+	}
+	return (bid[0]*ask[1] + ask[0]*bid[1]) / (bid[1] + ask[1])
+}
+
+func WeightedMid(book *models.OrderBookResult, levels int) float64 {
+	value, size := 0.0, 0.0
+	for _, side := range [][][]float64{book.Bids, book.Asks} {
+		for _, level := range side[:min(levels, len(side))] {
+			value += level[0] * level[1]
+			size += level[1]
+		}
+	}
+	if size == 0 {
+		return (book.Bids[0][0] + book.Asks[0][0]) / 2
+	}
+	return value / size
+}
+
+// DepthWithin sums the size resting within band (a fraction) of mid
+func DepthWithin(book *models.OrderBookResult, band float64, spec *models.Instrument) DepthBand {
+	mid := (book.Bids[0][0] + book.Asks[0][0]) / 2
+	depth := DepthBand{Band: band}
+	for _, level := range book.Bids {
+		if level[0] < mid*(1-band) {
+			break
+		}
+		depth.Bid += level[1]
+		depth.BidNotional += spec.Notional(level[1], level[0])
+	}
+	for _, level := range book.Asks {
+		if level[0] > mid*(1+band) {
+			break
+		}
+		depth.Ask += level[1]
+		depth.AskNotional += spec.Notional(level[1], level[0])
+	}
+	return depth
+}
+
+// EstimateFill walks levels, sorted from the best price, with a market
+// order of notional USD. The average price is the USD filled over the
+// base currency bought, which on inverse contracts, sized in USD, is the
+// harmonic mean of the prices.
+func EstimateFill(levels [][]float64, mid, notional float64, spec *models.Instrument) Fill {
+	fill := Fill{Notional: notional}
+	base := 0.0
+	for _, level := range levels {
+		if fill.Filled >= notional {
+			break
+		}
+		price, size := level[0], level[1]
+		levelNotional := spec.Notional(size, price)
+		if levelNotional <= 0 {
+			continue
+		}
+
+		// Take the part of the level the rest of the order needs
+		take := math.Min(1, (notional-fill.Filled)/levelNotional)
+		fill.Filled += levelNotional * take
+		base += spec.BaseAmount(size*take, price)
+		fill.Levels++
+	}
+
+	if base > 0 {
+		fill.AvgPrice = fill.Filled / base
+		fill.Slippage = math.Abs(fill.AvgPrice-mid) / mid * 10_000
+	}
+	return fill
+}
@@ -0,0 +1,33 @@
+package candles
+
+import "time"
+
+// Resolution is a bar size the chart can show. History is requested from
+// Deribit at Source and aggregated up locally when Deribit has no such
+// resolution itself (there is no 4h, for example).
+type Resolution struct {
+	Name     string
+	Duration time.Duration
+	// Source is the Deribit resolution backfilled and aggregated from
+	Source         string
+	SourceDuration time.Duration
+}
+
+// Resolutions are the bar sizes offered in the chart, smallest first
+var Resolutions = []Resolution{
+	{Name: "1m", Duration: time.Minute, Source: "1", SourceDuration: time.Minute},
+	{Name: "5m", Duration: 5 * time.Minute, Source: "5", SourceDuration: 5 * time.Minute},
+	{Name: "15m", Duration: 15 * time.Minute, Source: "15", SourceDuration: 15 * time.Minute},
+	{Name: "1h", Duration: time.Hour, Source: "60", SourceDuration: time.Hour},
+	{Name: "4h", Duration: 4 * time.Hour, Source: "60", SourceDuration: time.Hour},
+	{Name: "1D", Duration: 24 * time.Hour, Source: "1D", SourceDuration: 24 * time.Hour},
+}
+
+// ResolutionNames lists the resolution names in order, for pickers
+func ResolutionNames() []string {
+	names := make([]string, len(Resolutions))
+	for i, resolution := range Resolutions {
+		names[i] = resolution.Name
+	}
+	return names
+}
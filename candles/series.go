@@ -0,0 +1,146 @@
+package candles
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Series aggregates trades and lower resolution bars into OHLCV bars of a
+// single resolution. Bars start on multiples of the resolution in UTC (so
+// 4h bars open at 00:00, 04:00, ...), and bars without trades are filled
+// flat at the previous close so every bar has the same width on a chart.
+type Series struct {
+	Resolution time.Duration
+	// MaxBars bounds the history, the oldest bars are dropped beyond it
+	MaxBars int
+
+	candles []models.Candle
+}
+
+func NewSeries(resolution time.Duration, maxBars int) *Series {
+	return &Series{
+		Resolution: resolution,
+		MaxBars:    maxBars,
+	}
+}
+
+// Candles returns the bars oldest first. The slice is reused by the
+// series, so it must not be kept across updates.
+func (s *Series) Candles() []models.Candle {
+	return s.candles
+}
+
+// Last returns the newest bar
+func (s *Series) Last() (models.Candle, bool) {
+	if len(s.candles) == 0 {
+		return models.Candle{}, false
+	}
+	return s.candles[len(s.candles)-1], true
+}
+
+// Reset drops every bar
+func (s *Series) Reset() {
+	s.candles = s.candles[:0]
+}
+
+// BarStart returns the start of the bar that t falls into
+func (s *Series) BarStart(t time.Time) time.Time {
+	return t.UTC().Truncate(s.Resolution)
+}
+
+// AddTrade adds a trade to its bar. A trade exactly on a boundary opens
+// the new bar. Late trades for an earlier bar update its range and volume
+// but never its close. The amount is added to the volume as it is, so it
+// must be in the unit of the bars.
+func (s *Series) AddTrade(trade models.Trade) {
+	start := s.BarStart(trade.Timestamp)
+
+	if n := len(s.candles); n > 0 && start.Before(s.candles[n-1].Time) {
+		i := s.find(start)
+		if i < 0 {
+			return
+		}
+		bar := &s.candles[i]
+		bar.High = math.Max(bar.High, trade.Price)
+		bar.Low = math.Min(bar.Low, trade.Price)
+		bar.Volume += trade.Amount
+		return
+	}
+
+	s.merge(models.Candle{
+		Time:   start,
+		Open:   trade.Price,
+		High:   trade.Price,
+		Low:    trade.Price,
+		Close:  trade.Price,
+		Volume: trade.Amount,
+	})
+}
+
+// AddCandle merges a bar of this or a smaller resolution into the series.
+// Bars must be added oldest first, bars older than the newest one are ignored.
+func (s *Series) AddCandle(candle models.Candle) {
+	candle.Time = s.BarStart(candle.Time)
+	if n := len(s.candles); n > 0 && candle.Time.Before(s.candles[n-1].Time) {
+		return
+	}
+	s.merge(candle)
+}
+
+// merge folds a bar that starts at or after the newest one into the series
+func (s *Series) merge(candle models.Candle) {
+	n := len(s.candles)
+	if n > 0 && candle.Time.Equal(s.candles[n-1].Time) {
+		bar := &s.candles[n-1]
+		bar.High = math.Max(bar.High, candle.High)
+		bar.Low = math.Min(bar.Low, candle.Low)
+		bar.Close = candle.Close
+		bar.Volume += candle.Volume
+		return
+	}
+
+	if n > 0 {
+		// Fill bars without trades, at most a full history worth
+		previous := s.candles[n-1]
+		gap := int(candle.Time.Sub(previous.Time)/s.Resolution) - 1
+		if s.MaxBars > 0 {
+			gap = min(gap, s.MaxBars)
+		}
+		for i := gap; i >= 1; i-- {
+			s.candles = append(s.candles, models.Candle{
+				Time:  candle.Time.Add(-time.Duration(i) * s.Resolution),
+				Open:  previous.Close,
+				High:  previous.Close,
+				Low:   previous.Close,
+				Close: previous.Close,
+			})
+		}
+	}
+
+	s.candles = append(s.candles, candle)
+	s.trim()
+}
+
+// find returns the index of the bar starting at start, or -1
+func (s *Series) find(start time.Time) int {
+	i := sort.Search(len(s.candles), func(i int) bool {
+		return !s.candles[i].Time.Before(start)
+	})
+	if i < len(s.candles) && s.candles[i].Time.Equal(start) {
+		return i
+	}
+	return -1
+}
+
+func (s *Series) trim() {
+	if s.MaxBars <= 0 || len(s.candles) <= s.MaxBars {
+		return
+	}
+	// Shift down instead of reslicing so the backing array doesn't grow forever
+	excess := len(s.candles) - s.MaxBars
+	copy(s.candles, s.candles[excess:])
+	s.candles = s.candles[:s.MaxBars]
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+// bookDepthChannel is the book of instrument cut by Deribit to depth levels
+// per side, 1, 10 or 20, sent whole every interval
+func (c *DeribitClient) bookDepthChannel(instrument string, depth int) string {
+	return fmt.Sprintf("book.%s.none.%d.%s", instrument, depth, Interval100ms)
+}
+
+// bookDepthHandler publishes the books of a depth channel
+func (c *DeribitClient) bookDepthHandler(depth int) func(json.RawMessage) {
+	return func(data json.RawMessage) {
+		var orderBook models.OrderBookResult
+		if err := json.Unmarshal(data, &orderBook); err != nil {
+			log.Printf("Failed to unmarshal book: %v", err)
+			return
+		}
+
+		// Books for an instrument we switched away from are dropped
+		if orderBook.InstrumentName != c.Instrument() {
+			return
+		}
+		if len(orderBook.Bids) > 0 {
+			orderBook.BestBidPrice, orderBook.BestBidAmount = orderBook.Bids[0][0], orderBook.Bids[0][1]
+		}
+		if len(orderBook.Asks) > 0 {
+			orderBook.BestAskPrice, orderBook.BestAskAmount = orderBook.Asks[0][0], orderBook.Asks[0][1]
+		}
+		c.Store.Publish(orderBook.InstrumentName, marketdata.BookDepthKind(depth), &orderBook)
+	}
+}
+
+// WatchBookDepth sets the depth of the selected instrument's book that
+// owner follows, zero for none. Every owner has its own depth and the
+// client follows them all. Like SwitchInstrument it returns straight away
+// and moves the subscriptions in the background.
+func (c *DeribitClient) WatchBookDepth(owner string, depth int) {
+	c.mu.Lock()
+	c.depthRequests[owner] = depth
+	c.mu.Unlock()
+
+	go c.syncBookDepths()
+}
+
+// syncBookDepths subscribes to the selected instrument's books at the
+// latest depths asked for and unsubscribes from those no longer wanted.
+// Before the first instrument is subscribed the depths are only recorded.
+func (c *DeribitClient) syncBookDepths() {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	c.mu.Lock()
+	wanted := make(map[int]bool)
+	for _, depth := range c.depthRequests {
+		if depth > 0 {
+			wanted[depth] = true
+		}
+	}
+	c.mu.Unlock()
+
+	instrument := c.subscribedInstrument
+	if instrument != "" {
+		var stale []string
+		for depth := range c.depths {
+			if !wanted[depth] {
+				stale = append(stale, c.bookDepthChannel(instrument, depth))
+			}
+		}
+		added := make(map[string]func(json.RawMessage))
+		for depth := range wanted {
+			if !c.depths[depth] {
+				added[c.bookDepthChannel(instrument, depth)] = c.bookDepthHandler(depth)
+			}
+		}
+
+		if err := c.unsubscribe(stale...); err != nil {
+			log.Printf("Failed to unsubscribe from %s books: %v", instrument, err)
+		}
+		if err := c.subscribe(added); err != nil {
+			log.Printf("Failed to subscribe to %s books: %v", instrument, err)
+		}
+	}
+	c.depths = wanted
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// FetchCandles loads historical bars for an instrument. Resolution uses
+// Deribit's notation: minutes as a number ("1", "60") or "1D".
+func (c *DeribitClient) FetchCandles(ctx context.Context, instrument, resolution string, start, end time.Time) ([]models.Candle, error) {
+	var data models.ChartData
+	err := c.Call(ctx, "public/get_tradingview_chart_data", models.ChartDataParams{
+		InstrumentName: instrument,
+		StartTimestamp: start.UnixMilli(),
+		EndTimestamp:   end.UnixMilli(),
+		Resolution:     resolution,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data.Candles(), nil
+}
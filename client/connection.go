@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/adityanagar10/trader/analytics"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/gorilla/websocket"
+)
+
+// ConnState is the state of the connection to Deribit as shown in the UI
+type ConnState int32
+
+const (
+	StateConnecting ConnState = iota
+	StateLive
+	StateReconnecting
+	StateDown
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateLive:
+		return "live"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+const (
+	// DefaultURL is the Deribit production WebSocket endpoint
+	DefaultURL = "wss://www.deribit.com/ws/api/v2"
+
+	// Reconnect delays grow from minBackoff up to maxBackoff
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+
+	// Failed attempts after which the connection is reported down.
+	// The supervisor keeps retrying at maxBackoff after that.
+	downAfterAttempts = 5
+
+	// heartbeatInterval is requested from Deribit with public/set_heartbeat,
+	// a connection that stays silent for three intervals is treated as dead
+	heartbeatInterval = 10
+)
+
+// backoff returns the delay before reconnect attempt n (starting at 0),
+// exponential with jitter so many clients don't reconnect in lockstep
+func backoff(attempt int) time.Duration {
+	delay := maxBackoff
+	if attempt < 16 {
+		delay = min(minBackoff<<attempt, maxBackoff)
+	}
+	// Pick uniformly from the upper half of the window
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// State returns the current connection state
+func (c *DeribitClient) State() ConnState {
+	return ConnState(c.state.Load())
+}
+
+// OnStateChange registers a listener for connection state transitions.
+// Listeners run on the supervisor goroutine and must not block.
+func (c *DeribitClient) OnStateChange(listener func(ConnState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stateListeners = append(c.stateListeners, listener)
+}
+
+func (c *DeribitClient) setState(state ConnState) {
+	if ConnState(c.state.Swap(int32(state))) == state {
+		return
+	}
+	log.Printf("Deribit connection %s", state)
+
+	c.mu.Lock()
+	listeners := append([]func(ConnState){}, c.stateListeners...)
+	c.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(state)
+	}
+}
+
+func (c *DeribitClient) dial() (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("websocket connection error: %v", err)
+	}
+	return conn, nil
+}
+
+// supervise owns the connection: it serves conn until it drops, then
+// redials with backoff until Close is called. Only a session that went
+// live resets the backoff, so a connection that is refused its session
+// keeps backing off towards StateDown.
+func (c *DeribitClient) supervise(conn *websocket.Conn) {
+	attempt := 0
+	for {
+		if conn != nil && c.serve(conn) {
+			attempt = 0
+		}
+
+		select {
+		case <-c.done:
+			c.setState(StateDown)
+			return
+		default:
+		}
+
+		if attempt >= downAfterAttempts {
+			c.setState(StateDown)
+		} else {
+			c.setState(StateReconnecting)
+		}
+
+		delay := c.reconnectDelay(attempt)
+		attempt++
+		log.Printf("Reconnecting to Deribit in %v (attempt %d)", delay.Round(time.Millisecond), attempt)
+
+		select {
+		case <-c.done:
+			c.setState(StateDown)
+			return
+		case <-time.After(delay):
+		}
+
+		var err error
+		conn, err = c.dial()
+		if err != nil {
+			log.Printf("Reconnect failed: %v", err)
+			conn = nil
+		}
+	}
+}
+
+// serve runs one connection: it restores the session, then blocks
+// until the connection drops or the client is closed. It reports whether
+// the session went live.
+func (c *DeribitClient) serve(conn *websocket.Conn) bool {
+	// Whatever we had is stale, wait for the snapshot of the new session
+	c.book.reset()
+	c.Store.Publish(c.Instrument(), marketdata.KindBookMetrics, (*analytics.Metrics)(nil))
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	readDone := make(chan struct{})
+	go func() {
+		c.handleMessages(conn)
+		close(readDone)
+	}()
+
+	live := false
+	if err := c.restoreSession(); err != nil {
+		log.Printf("Failed to restore session: %v", err)
+		conn.Close()
+	} else {
+		live = true
+		log.Println("Connected to Deribit WebSocket API")
+		c.setState(StateLive)
+		go c.loadInstrument(c.Instrument())
+	}
+
+	select {
+	case <-readDone:
+	case <-c.done:
+		conn.Close()
+		<-readDone
+	}
+
+	c.mu.Lock()
+	c.conn = nil
+	c.mu.Unlock()
+	c.failPending()
+	return live
+}
+
+// restoreSession sets up a fresh connection: heartbeats, authentication
+// and every subscription that was active before
+func (c *DeribitClient) restoreSession() error {
+	ctx := context.Background()
+
+	if err := c.Call(ctx, "public/set_heartbeat", models.HeartbeatParams{Interval: heartbeatInterval}, nil); err != nil {
+		return fmt.Errorf("set_heartbeat: %w", err)
+	}
+
+	if c.ClientID != "" {
+		if err := c.Call(ctx, "public/auth", models.AuthParams{
+			GrantType:    "client_credentials",
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+		}, nil); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	channels := c.activeChannels()
+	if len(channels) == 0 {
+		return nil
+	}
+
+	var subscribed []string
+	if err := c.Call(ctx, "public/subscribe", models.SubscriptionParams{Channels: channels}, &subscribed); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	if len(subscribed) != len(channels) {
+		log.Printf("Restored %d of %d subscriptions", len(subscribed), len(channels))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/gorilla/websocket"
+)
+
+// fakeDeribit is a local stand-in for the Deribit WebSocket API. It
+// answers the calls a session restore makes and records every
+// subscription.
+type fakeDeribit struct {
+	server *httptest.Server
+	// rejectSubscribe makes public/subscribe fail
+	rejectSubscribe bool
+
+	mu         sync.Mutex
+	conns      []*websocket.Conn
+	subscribes [][]string
+}
+
+func newFakeDeribit(t *testing.T, rejectSubscribe bool) *fakeDeribit {
+	f := &fakeDeribit{rejectSubscribe: rejectSubscribe}
+	upgrader := websocket.Upgrader{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.mu.Unlock()
+		f.serve(conn)
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeDeribit) url() string {
+	return "ws" + strings.TrimPrefix(f.server.URL, "http")
+}
+
+func (f *fakeDeribit) serve(conn *websocket.Conn) {
+	defer conn.Close()
+	for {
+		var request struct {
+			ID     int                       `json:"id"`
+			Method string                    `json:"method"`
+			Params models.SubscriptionParams `json:"params"`
+		}
+		if err := conn.ReadJSON(&request); err != nil {
+			return
+		}
+
+		response := models.DeribitResponse{JsonRPC: "2.0", ID: request.ID}
+		switch {
+		case request.Method == "public/subscribe" && f.rejectSubscribe:
+			response.Error = &models.DeribitError{Code: 11050, Message: "bad_request"}
+		case request.Method == "public/subscribe":
+			f.mu.Lock()
+			f.subscribes = append(f.subscribes, request.Params.Channels)
+			f.mu.Unlock()
+			response.Result, _ = json.Marshal(request.Params.Channels)
+		case request.Method == "public/unsubscribe":
+			response.Result, _ = json.Marshal(request.Params.Channels)
+		case request.Method == "public/set_heartbeat":
+			response.Result = json.RawMessage(`"ok"`)
+		default:
+			response.Error = &models.DeribitError{Code: -32601, Message: "Method not found"}
+		}
+		if err := conn.WriteJSON(response); err != nil {
+			return
+		}
+	}
+}
+
+// drop closes every open connection, as a network failure would
+func (f *fakeDeribit) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeDeribit) subscriptions() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.subscribes)
+}
+
+// testClient connects a client to f, recording its state changes and
+// the attempt number of every reconnect delay
+func testClient(t *testing.T, f *fakeDeribit) (*DeribitClient, <-chan ConnState, func() []int) {
+	c := NewDeribitClient("BTC-PERPETUAL", marketdata.NewStore())
+	c.URL = f.url()
+	c.CallTimeout = time.Second
+
+	var mu sync.Mutex
+	var attempts []int
+	c.reconnectDelay = func(attempt int) time.Duration {
+		mu.Lock()
+		attempts = append(attempts, attempt)
+		mu.Unlock()
+		return time.Millisecond
+	}
+
+	states := make(chan ConnState, 100)
+	c.OnStateChange(func(state ConnState) {
+		states <- state
+	})
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(c.Close)
+
+	return c, states, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(attempts)
+	}
+}
+
+func waitForState(t *testing.T, states <-chan ConnState, want ConnState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if state == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+}
+
+func TestSupervisorReconnectsAndResubscribes(t *testing.T) {
+	f := newFakeDeribit(t, false)
+	c, states, attempts := testClient(t, f)
+	waitForState(t, states, StateLive)
+
+	f.drop()
+	waitForState(t, states, StateReconnecting)
+	waitForState(t, states, StateLive)
+
+	subscribes := f.subscriptions()
+	if len(subscribes) != 2 {
+		t.Fatalf("got %d subscribe calls, want 2", len(subscribes))
+	}
+	for i, channels := range subscribes {
+		if !slices.Contains(channels, c.bookChannel("BTC-PERPETUAL")) {
+			t.Errorf("subscribe %d is missing the book channel: %v", i, channels)
+		}
+	}
+	if got := attempts(); !slices.Equal(got, []int{0}) {
+		t.Errorf("reconnect attempts = %v, want [0]", got)
+	}
+}
+
+func TestSupervisorBacksOffWhenSessionFails(t *testing.T) {
+	f := newFakeDeribit(t, true)
+	_, states, attempts := testClient(t, f)
+
+	// Every dial succeeds but the session never goes live, so the
+	// attempts keep counting up until the connection is reported down
+	waitForState(t, states, StateDown)
+	got := attempts()
+	if len(got) <= downAfterAttempts {
+		t.Fatalf("down after %d attempts, want more than %d", len(got), downAfterAttempts)
+	}
+	for i, attempt := range got {
+		if attempt != i {
+			t.Fatalf("reconnect attempts = %v, want them counting up from 0", got)
+		}
+	}
+}
+
+func TestBackoffGrows(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		window := maxBackoff
+		if attempt < 16 {
+			window = min(minBackoff<<attempt, maxBackoff)
+		}
+		for range 100 {
+			delay := backoff(attempt)
+			if delay < window/2 || delay > window {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, delay, window/2, window)
+			}
+		}
+	}
+}
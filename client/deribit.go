@@ -2,34 +2,114 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/adityanagar10/trader/analytics"
+	"github.com/adityanagar10/trader/marketdata"
 	"github.com/adityanagar10/trader/models"
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// IntervalRaw delivers every book change as it happens
+	IntervalRaw = "raw"
+	// Interval100ms groups book changes into 100ms batches
+	Interval100ms = "100ms"
+)
+
 type DeribitClient struct {
-	Conn            *websocket.Conn
-	OrderBookWindow *models.Window
-	Instrument      string
-	RequestID       int
+	// Store receives every snapshot the client builds
+	Store *marketdata.Store
+	// Interval selects the book and trades channels: IntervalRaw or Interval100ms.
+	// Deribit only serves raw channels on authorized connections.
+	Interval string
+
+	// URL is the WebSocket endpoint, DefaultURL unless pointed elsewhere
+	URL string
+	// ClientID and ClientSecret are optional API credentials, used to
+	// authenticate every new connection when set
+	ClientID     string
+	ClientSecret string
+	// CallTimeout applies to calls made without a context deadline
+	CallTimeout time.Duration
+	// reconnectDelay is the wait before a reconnect attempt, backoff
+	// unless replaced in tests
+	reconnectDelay func(attempt int) time.Duration
+
+	mu             sync.Mutex
+	instrument     string
+	conn           *websocket.Conn
+	channels       map[string]func(json.RawMessage)
+	pending        map[int]chan *models.DeribitResponse
+	stateListeners []func(ConnState)
+	state          atomic.Int32
+	requestID      atomic.Int64
+	done           chan struct{}
+	closeOnce      sync.Once
+
+	// switchMu serializes moving the instrument channels between instruments
+	switchMu             sync.Mutex
+	subscribedInstrument string
+	// watched are the instruments whose tickers are followed for other
+	// windows, watchRequests the latest sets asked for by each owner
+	watched       map[string]bool
+	watchRequests map[string][]string
+	// marksIndex is the index whose option marks are followed,
+	// marksRequest the latest asked for
+	marksIndex   string
+	marksRequest string
+	// depths are the book depths followed for the selected instrument,
+	// depthRequests the latest asked for by each owner
+	depths        map[int]bool
+	depthRequests map[string]int
+
+	writeMu sync.Mutex
+	book    localBook
+	resyncs atomic.Int64
+	trades  tradeHistory
 }
 
-func NewDeribitClient(instrument string, orderBookWindow *models.Window) *DeribitClient {
+func NewDeribitClient(instrument string, store *marketdata.Store) *DeribitClient {
 	return &DeribitClient{
-		OrderBookWindow: orderBookWindow,
-		Instrument:      instrument,
-		RequestID:       1,
+		Store:          store,
+		instrument:     instrument,
+		Interval:       Interval100ms,
+		URL:            DefaultURL,
+		CallTimeout:    DefaultCallTimeout,
+		reconnectDelay: backoff,
+		channels:       make(map[string]func(json.RawMessage)),
+		pending:        make(map[int]chan *models.DeribitResponse),
+		done:           make(chan struct{}),
+		watchRequests:  make(map[string][]string),
+		depthRequests:  make(map[string]int),
 	}
 }
 
-func (c *DeribitClient) handleMessages() {
+// Instrument returns the instrument the client currently follows
+func (c *DeribitClient) Instrument() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.instrument
+}
+
+func (c *DeribitClient) bookChannel(instrument string) string {
+	return fmt.Sprintf("book.%s.%s", instrument, c.Interval)
+}
+
+func (c *DeribitClient) handleMessages(conn *websocket.Conn) {
 	for {
-		_, message, err := c.Conn.ReadMessage()
+		// Heartbeats guarantee traffic, so silence means a dead connection
+		conn.SetReadDeadline(time.Now().Add(3 * heartbeatInterval * time.Second))
+
+		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Printf("WebSocket read error: %v", err)
+			conn.Close()
 			return
 		}
 
@@ -40,76 +120,117 @@ func (c *DeribitClient) handleMessages() {
 			continue
 		}
 
-		// Handle errors
-		if response.Error != nil {
-			log.Printf("Deribit API error: %d - %s", response.Error.Code, response.Error.Message)
-			continue
+		switch {
+		case response.Method == "heartbeat" && response.Params != nil:
+			// Deribit drops connections that don't answer a test request
+			if response.Params.Type == "test_request" {
+				if err := c.send("public/test", struct{}{}); err != nil {
+					log.Printf("Failed to answer heartbeat: %v", err)
+				}
+			}
+		case response.Method == "subscription" && response.Params != nil:
+			c.handleNotification(response.Params)
+		case response.Method == "":
+			c.deliver(&response)
 		}
+	}
+}
 
-		// Process order book responses
-		if response.Result != nil {
-			// Update window data directly with the complete order book result
-			c.OrderBookWindow.Data = response.Result
-		}
+// handleNotification routes subscription data to the handler of its channel
+func (c *DeribitClient) handleNotification(params *models.NotificationParams) {
+	c.mu.Lock()
+	handler, ok := c.channels[params.Channel]
+	c.mu.Unlock()
+
+	// Late notifications for channels we no longer follow are dropped
+	if ok {
+		handler(params.Data)
 	}
 }
 
-func (c *DeribitClient) fetchOrderBook() {
-	// Create an order book request
-	request := models.DeribitRequest{
-		JsonRPC: "2.0",
-		ID:      c.RequestID,
-		Method:  "public/get_order_book",
-		Params: models.OrderBookParams{
-			InstrumentName: c.Instrument,
-		},
+func (c *DeribitClient) handleBookUpdate(data json.RawMessage) {
+	var update models.BookUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		log.Printf("Failed to unmarshal book update: %v", err)
+		return
 	}
-	c.RequestID++
 
-	// Marshal and send the request
-	data, err := json.Marshal(request)
-	if err != nil {
-		log.Printf("Failed to marshal request: %v", err)
+	// Updates for an instrument we switched away from are dropped
+	if update.InstrumentName != c.Instrument() {
 		return
 	}
 
-	if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		log.Printf("Failed to send request: %v", err)
+	if err := c.book.apply(&update); err != nil {
+		switch {
+		case errors.Is(err, errBookNotReady):
+			// Still waiting for the snapshot of a resync
+		case errors.Is(err, errBookGap):
+			log.Printf("Order book for %s out of sequence, resyncing: %v", update.InstrumentName, err)
+			c.resyncOrderBook(update.InstrumentName)
+		default:
+			log.Printf("Failed to apply book update: %v", err)
+		}
 		return
 	}
-}
 
-func (c *DeribitClient) fetchOrderBookPeriodically() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	// Every update publishes a fresh copy of the book
+	snapshot := c.book.snapshot()
+	snapshot.Resyncs = c.resyncs.Load()
+	c.Store.Publish(update.InstrumentName, marketdata.KindOrderBook, snapshot)
 
-	for range ticker.C {
-		c.fetchOrderBook()
-	}
+	// Analytics follow every book so any panel can show them, nil while
+	// a side is empty
+	spec := c.Store.Instrument(update.InstrumentName)
+	metrics := analytics.Compute(snapshot, spec, analytics.DefaultConfig)
+	c.Store.Publish(update.InstrumentName, marketdata.KindBookMetrics, metrics)
 }
 
-func (c *DeribitClient) Connect() error {
-	// Connect to Deribit WebSocket API
-	conn, _, err := websocket.DefaultDialer.Dial("wss://www.deribit.com/ws/api/v2", nil)
-	if err != nil {
-		return fmt.Errorf("websocket connection error: %v", err)
+// resyncOrderBook drops the local book and resubscribes to the book
+// channel, which makes Deribit send a fresh snapshot to resume from
+func (c *DeribitClient) resyncOrderBook(instrument string) {
+	c.book.reset()
+	count := c.resyncs.Add(1)
+
+	// Show the resync instead of drawing the corrupted book or its analytics
+	c.Store.Publish(instrument, marketdata.KindOrderBook, &models.OrderBookResult{
+		InstrumentName: instrument,
+		Resyncing:      true,
+		Resyncs:        count,
+	})
+	c.Store.Publish(instrument, marketdata.KindBookMetrics, (*analytics.Metrics)(nil))
+
+	channel := c.bookChannel(instrument)
+	channels := models.SubscriptionParams{Channels: []string{channel}}
+	if err := c.send("public/unsubscribe", channels); err != nil {
+		log.Printf("Failed to unsubscribe from %s: %v", channel, err)
 	}
+	if err := c.send("public/subscribe", channels); err != nil {
+		log.Printf("Failed to resubscribe to %s: %v", channel, err)
+	}
+}
 
-	c.Conn = conn
-	log.Println("Connected to Deribit WebSocket API")
+// ResyncCount returns how many times the order book had to be resynced
+func (c *DeribitClient) ResyncCount() int64 {
+	return c.resyncs.Load()
+}
 
-	// Start listening for messages
-	go c.handleMessages()
+// Connect dials Deribit and hands the connection to a supervisor that
+// reconnects whenever it drops. If the first dial fails the error is
+// returned, but the supervisor keeps retrying in the background.
+func (c *DeribitClient) Connect() error {
+	// Channels are recorded now and subscribed once the session is up
+	c.syncInstrumentChannels()
 
-	// Start fetching order book periodically
-	go c.fetchOrderBookPeriodically()
+	c.setState(StateConnecting)
+	conn, err := c.dial()
+	go c.supervise(conn)
 
-	return nil
+	return err
 }
 
 func (c *DeribitClient) Close() {
-	if c.Conn != nil {
-		c.Conn.Close()
+	c.closeOnce.Do(func() {
+		close(c.done)
 		log.Println("Closed Deribit WebSocket connection")
-	}
+	})
 }
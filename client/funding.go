@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+const (
+	// fundingChunk is the range of each history request, well within what
+	// Deribit returns at once
+	fundingChunk = 7 * 24 * time.Hour
+	// fundingRefresh is how often the history is reloaded, it gains an
+	// entry every hour
+	fundingRefresh = time.Hour
+	fundingRetry   = time.Minute
+)
+
+// FetchFundingRateHistory loads the hourly funding of a perpetual between
+// start and end, oldest first
+func (c *DeribitClient) FetchFundingRateHistory(ctx context.Context, instrument string, start, end time.Time) ([]models.FundingRate, error) {
+	var history []models.FundingRate
+	for from := start; from.Before(end); from = from.Add(fundingChunk) {
+		to := from.Add(fundingChunk)
+		if to.After(end) {
+			to = end
+		}
+
+		var result []models.FundingRate
+		err := c.Call(ctx, "public/get_funding_rate_history", models.FundingRateHistoryParams{
+			InstrumentName: instrument,
+			StartTimestamp: from.UnixMilli(),
+			EndTimestamp:   to.UnixMilli(),
+		}, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		// Chunks share their boundary hour
+		for _, rate := range result {
+			if len(history) == 0 || rate.Timestamp > history[len(history)-1].Timestamp {
+				history = append(history, rate)
+			}
+		}
+	}
+	return history, nil
+}
+
+// FollowFundingHistory keeps window of the funding history of each
+// perpetual in the store, loading it once connected and again every hour
+// until the client is closed
+func (c *DeribitClient) FollowFundingHistory(instruments []string, window time.Duration) {
+	// Connecting wakes the loop instead of waiting for the retry
+	live := make(chan struct{}, 1)
+	c.OnStateChange(func(state ConnState) {
+		if state == StateLive {
+			select {
+			case live <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	go func() {
+		for {
+			wait := fundingRetry
+			if c.State() == StateLive && c.loadFundingHistory(instruments, window) {
+				wait = fundingRefresh
+			}
+
+			select {
+			case <-c.done:
+				return
+			case <-live:
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// loadFundingHistory publishes the history of every instrument and
+// reports whether all of them loaded
+func (c *DeribitClient) loadFundingHistory(instruments []string, window time.Duration) bool {
+	ok := true
+	end := time.Now()
+	for _, instrument := range instruments {
+		history, err := c.FetchFundingRateHistory(context.Background(), instrument, end.Add(-window), end)
+		if err != nil {
+			log.Printf("Failed to load %s funding history: %v", instrument, err)
+			ok = false
+			continue
+		}
+		c.Store.Publish(instrument, marketdata.KindFundingHistory, history)
+	}
+	return ok
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"log"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+// FetchInstrument loads the contract specification of an instrument
+func (c *DeribitClient) FetchInstrument(ctx context.Context, instrument string) (*models.Instrument, error) {
+	var result models.Instrument
+	err := c.Call(ctx, "public/get_instrument", models.InstrumentParams{InstrumentName: instrument}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// loadInstrument publishes the specification of an instrument to the
+// store, unless it is already there. It needs a live connection.
+func (c *DeribitClient) loadInstrument(instrument string) {
+	if _, ok := c.Store.Get(instrument, marketdata.KindInstrument); ok {
+		return
+	}
+
+	spec, err := c.FetchInstrument(context.Background(), instrument)
+	if err != nil {
+		log.Printf("Failed to load instrument %s: %v", instrument, err)
+		return
+	}
+	c.Store.Publish(instrument, marketdata.KindInstrument, spec)
+}
+
+// FetchCurrencies lists the currencies Deribit has instruments in
+func (c *DeribitClient) FetchCurrencies(ctx context.Context) ([]string, error) {
+	var result []models.Currency
+	if err := c.Call(ctx, "public/get_currencies", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	currencies := make([]string, len(result))
+	for i, currency := range result {
+		currencies[i] = currency.Currency
+	}
+	return currencies, nil
+}
+
+// FetchInstruments lists the active instruments of a currency and kind
+func (c *DeribitClient) FetchInstruments(ctx context.Context, currency, kind string) ([]models.Instrument, error) {
+	var result []models.Instrument
+	err := c.Call(ctx, "public/get_instruments", models.InstrumentsParams{
+		Currency: currency,
+		Kind:     kind,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+func (c *DeribitClient) optionMarksChannel(index string) string {
+	return "markprice.options." + index
+}
+
+// optionMarksHandler publishes the marks of the options on index
+func (c *DeribitClient) optionMarksHandler(index string) func(json.RawMessage) {
+	return func(data json.RawMessage) {
+		var marks []models.OptionMark
+		if err := json.Unmarshal(data, &marks); err != nil {
+			log.Printf("Failed to unmarshal option marks: %v", err)
+			return
+		}
+		c.Store.Publish(index, marketdata.KindOptionMarks, marks)
+	}
+}
+
+// WatchOptionMarks follows the marks of every option on a price index,
+// as in btc_usd, in place of the index followed before. An empty index
+// stops following. It returns straight away and moves the subscription in
+// the background.
+func (c *DeribitClient) WatchOptionMarks(index string) {
+	c.mu.Lock()
+	c.marksRequest = index
+	c.mu.Unlock()
+
+	go c.syncOptionMarks()
+}
+
+func (c *DeribitClient) syncOptionMarks() {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	c.mu.Lock()
+	index := c.marksRequest
+	c.mu.Unlock()
+	if index == c.marksIndex {
+		return
+	}
+
+	if c.marksIndex != "" {
+		if err := c.unsubscribe(c.optionMarksChannel(c.marksIndex)); err != nil {
+			log.Printf("Failed to unsubscribe from %s option marks: %v", c.marksIndex, err)
+		}
+	}
+	if index != "" {
+		err := c.subscribe(map[string]func(json.RawMessage){
+			c.optionMarksChannel(index): c.optionMarksHandler(index),
+		})
+		if err != nil {
+			log.Printf("Failed to subscribe to %s option marks: %v", index, err)
+		}
+	}
+	c.marksIndex = index
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// errBookGap means a change did not follow on from the last applied one,
+// so at least one message was missed and the local book is no longer valid
+var errBookGap = errors.New("order book sequence gap")
+
+// errBookNotReady means a change arrived while waiting for a snapshot
+var errBookNotReady = errors.New("order book waiting for snapshot")
+
+type bookLevel struct {
+	price  float64
+	amount float64
+}
+
+// localBook is the order book rebuilt from a subscription snapshot and
+// the incremental changes that follow it. Bids are kept sorted from best
+// (highest) to worst, asks from best (lowest) to worst.
+type localBook struct {
+	instrument string
+	timestamp  int64
+	changeID   int64
+	bids       []bookLevel
+	asks       []bookLevel
+	ready      bool
+}
+
+// apply merges a book notification into the local book
+func (b *localBook) apply(update *models.BookUpdate) error {
+	switch update.Type {
+	case "snapshot":
+		b.instrument = update.InstrumentName
+		b.bids = b.bids[:0]
+		b.asks = b.asks[:0]
+		b.ready = true
+	case "change":
+		if !b.ready || update.InstrumentName != b.instrument {
+			return errBookNotReady
+		}
+		if update.PrevChangeID != b.changeID {
+			return fmt.Errorf("%w: expected prev_change_id %d, got %d",
+				errBookGap, b.changeID, update.PrevChangeID)
+		}
+	default:
+		return fmt.Errorf("unknown book update type %q", update.Type)
+	}
+
+	for _, level := range update.Bids {
+		b.bids = applyLevel(b.bids, level, true)
+	}
+	for _, level := range update.Asks {
+		b.asks = applyLevel(b.asks, level, false)
+	}
+
+	b.timestamp = update.Timestamp
+	b.changeID = update.ChangeID
+	return nil
+}
+
+// reset drops the local book until the next snapshot arrives
+func (b *localBook) reset() {
+	b.bids = b.bids[:0]
+	b.asks = b.asks[:0]
+	b.ready = false
+}
+
+// applyLevel inserts, updates or removes a price level keeping the side sorted
+func applyLevel(levels []bookLevel, update models.BookLevelUpdate, descending bool) []bookLevel {
+	i := sort.Search(len(levels), func(i int) bool {
+		if descending {
+			return levels[i].price <= update.Price
+		}
+		return levels[i].price >= update.Price
+	})
+	found := i < len(levels) && levels[i].price == update.Price
+
+	if update.Action == "delete" || update.Amount == 0 {
+		if found {
+			levels = append(levels[:i], levels[i+1:]...)
+		}
+		return levels
+	}
+
+	if found {
+		levels[i].amount = update.Amount
+		return levels
+	}
+
+	levels = append(levels, bookLevel{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = bookLevel{price: update.Price, amount: update.Amount}
+	return levels
+}
+
+// snapshot copies the local book into a new OrderBookResult so readers
+// never share memory with the book being updated
+func (b *localBook) snapshot() *models.OrderBookResult {
+	result := &models.OrderBookResult{
+		Timestamp:      b.timestamp,
+		State:          "open",
+		ChangeID:       b.changeID,
+		InstrumentName: b.instrument,
+		Bids:           levelsToRows(b.bids),
+		Asks:           levelsToRows(b.asks),
+	}
+
+	if len(b.bids) > 0 {
+		result.BestBidPrice = b.bids[0].price
+		result.BestBidAmount = b.bids[0].amount
+	}
+	if len(b.asks) > 0 {
+		result.BestAskPrice = b.asks[0].price
+		result.BestAskAmount = b.asks[0].amount
+	}
+
+	return result
+}
+
+func levelsToRows(levels []bookLevel) [][]float64 {
+	// One backing array for the whole side keeps this to two allocations
+	backing := make([]float64, len(levels)*2)
+	rows := make([][]float64, len(levels))
+	for i, level := range levels {
+		row := backing[i*2 : i*2+2 : i*2+2]
+		row[0] = level.price
+		row[1] = level.amount
+		rows[i] = row
+	}
+	return rows
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultCallTimeout bounds a Call whose context has no deadline
+const DefaultCallTimeout = 10 * time.Second
+
+var (
+	errNotConnected   = errors.New("not connected")
+	errConnectionLost = errors.New("connection lost before response")
+	errClientClosed   = errors.New("client closed")
+)
+
+// Call sends a JSON-RPC request and waits for its response. The result is
+// decoded into result (which may be nil to discard it). API errors are
+// returned as *models.DeribitError.
+func (c *DeribitClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.CallTimeout)
+		defer cancel()
+	}
+
+	id := c.nextRequestID()
+	responses := make(chan *models.DeribitResponse, 1)
+
+	c.mu.Lock()
+	c.pending[id] = responses
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(id, method, params); err != nil {
+		return err
+	}
+
+	select {
+	case response := <-responses:
+		if response == nil {
+			return errConnectionLost
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		if result == nil || len(response.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("failed to unmarshal %s result: %v", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-c.done:
+		return errClientClosed
+	}
+}
+
+// send writes a request without waiting for the response, for use on the
+// read goroutine where waiting would deadlock. Errors in the response are
+// only logged.
+func (c *DeribitClient) send(method string, params interface{}) error {
+	return c.write(c.nextRequestID(), method, params)
+}
+
+func (c *DeribitClient) nextRequestID() int {
+	return int(c.requestID.Add(1))
+}
+
+func (c *DeribitClient) write(id int, method string, params interface{}) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return errNotConnected
+	}
+
+	request := models.DeribitRequest{
+		JsonRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+
+	// Marshal and send the request
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	return nil
+}
+
+// deliver routes a response to the Call waiting for it
+func (c *DeribitClient) deliver(response *models.DeribitResponse) {
+	c.mu.Lock()
+	responses, ok := c.pending[response.ID]
+	c.mu.Unlock()
+
+	if !ok {
+		// Reply to a send, nobody is waiting for it
+		if response.Error != nil {
+			log.Printf("Deribit API error: %d - %s", response.Error.Code, response.Error.Message)
+		}
+		return
+	}
+	responses <- response
+}
+
+// failPending wakes every Call still waiting on a connection that dropped
+func (c *DeribitClient) failPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, responses := range c.pending {
+		select {
+		case responses <- nil:
+		default:
+		}
+		delete(c.pending, id)
+	}
+}
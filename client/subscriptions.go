@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// subscribe records the channels and their handlers as active, so they are
+// restored after a reconnect, and subscribes to them if we are connected
+func (c *DeribitClient) subscribe(handlers map[string]func(json.RawMessage)) error {
+	channels := make([]string, 0, len(handlers))
+
+	c.mu.Lock()
+	for channel, handler := range handlers {
+		c.channels[channel] = handler
+		channels = append(channels, channel)
+	}
+	connected := c.conn != nil
+	c.mu.Unlock()
+
+	if !connected || len(channels) == 0 {
+		return nil
+	}
+	return c.Call(context.Background(), "public/subscribe", models.SubscriptionParams{
+		Channels: channels,
+	}, nil)
+}
+
+// unsubscribe forgets the channels straight away, so notifications still
+// in flight are dropped, then unsubscribes from them if we are connected
+func (c *DeribitClient) unsubscribe(channels ...string) error {
+	c.mu.Lock()
+	for _, channel := range channels {
+		delete(c.channels, channel)
+	}
+	connected := c.conn != nil
+	c.mu.Unlock()
+
+	if !connected || len(channels) == 0 {
+		return nil
+	}
+	return c.Call(context.Background(), "public/unsubscribe", models.SubscriptionParams{
+		Channels: channels,
+	}, nil)
+}
+
+func (c *DeribitClient) activeChannels() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	channels := make([]string, 0, len(c.channels))
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// instrumentChannels returns the channels followed for the selected
+// instrument along with the handler of each, the caller holds switchMu
+func (c *DeribitClient) instrumentChannels(instrument string) map[string]func(json.RawMessage) {
+	channels := map[string]func(json.RawMessage){
+		c.bookChannel(instrument):   c.handleBookUpdate,
+		c.tradesChannel(instrument): c.handleTrades,
+		c.tickerChannel(instrument): c.handleTicker,
+	}
+	for depth := range c.depths {
+		channels[c.bookDepthChannel(instrument, depth)] = c.bookDepthHandler(depth)
+	}
+	return channels
+}
+
+// SwitchInstrument makes the client follow another instrument. It returns
+// straight away; the old instrument's channels are unsubscribed and the new
+// ones subscribed in the background, and anything still arriving for the
+// old instrument is discarded.
+func (c *DeribitClient) SwitchInstrument(instrument string) {
+	c.mu.Lock()
+	c.instrument = instrument
+	c.mu.Unlock()
+
+	go c.syncInstrumentChannels()
+}
+
+// syncInstrumentChannels moves the instrument channels over to whatever
+// instrument is selected now. Switches run one at a time and each one
+// reads the latest selection, so quick successive switches settle on the
+// last instrument picked.
+func (c *DeribitClient) syncInstrumentChannels() {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	instrument := c.Instrument()
+	if instrument == c.subscribedInstrument {
+		return
+	}
+
+	if c.subscribedInstrument != "" {
+		var channels []string
+		for channel := range c.instrumentChannels(c.subscribedInstrument) {
+			// A watched ticker outlives the switch
+			if c.watched[c.subscribedInstrument] && channel == c.tickerChannel(c.subscribedInstrument) {
+				continue
+			}
+			channels = append(channels, channel)
+		}
+		if err := c.unsubscribe(channels...); err != nil {
+			log.Printf("Failed to unsubscribe from %s: %v", c.subscribedInstrument, err)
+		}
+	}
+
+	if err := c.subscribe(c.instrumentChannels(instrument)); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", instrument, err)
+	}
+	c.subscribedInstrument = instrument
+
+	// Before the first connection the session restore loads it instead
+	if c.State() == StateLive {
+		c.loadInstrument(instrument)
+	}
+}
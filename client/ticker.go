@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+func (c *DeribitClient) tickerChannel(instrument string) string {
+	return fmt.Sprintf("ticker.%s.%s", instrument, Interval100ms)
+}
+
+func (c *DeribitClient) handleTicker(data json.RawMessage) {
+	var ticker models.Ticker
+	if err := json.Unmarshal(data, &ticker); err != nil {
+		log.Printf("Failed to unmarshal ticker: %v", err)
+		return
+	}
+	c.Store.Publish(ticker.InstrumentName, marketdata.KindTicker, &ticker)
+}
+
+// WatchTickers sets the instruments whose tickers owner follows, besides
+// the selected instrument's. Every owner has its own set and the client
+// follows them all. Like SwitchInstrument it returns straight away and
+// moves the subscriptions in the background, settling on the latest call.
+func (c *DeribitClient) WatchTickers(owner string, instruments []string) {
+	c.mu.Lock()
+	c.watchRequests[owner] = append([]string(nil), instruments...)
+	c.mu.Unlock()
+
+	go c.syncWatchedTickers()
+}
+
+// syncWatchedTickers subscribes to the tickers of the latest watch requests
+// and unsubscribes from those no longer wanted. The selected instrument's
+// ticker belongs to the instrument channels and is left alone.
+func (c *DeribitClient) syncWatchedTickers() {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	c.mu.Lock()
+	wanted := make(map[string]bool)
+	for _, instruments := range c.watchRequests {
+		for _, instrument := range instruments {
+			wanted[instrument] = true
+		}
+	}
+	c.mu.Unlock()
+
+	var stale []string
+	for instrument := range c.watched {
+		if !wanted[instrument] && instrument != c.subscribedInstrument {
+			stale = append(stale, c.tickerChannel(instrument))
+		}
+	}
+	added := make(map[string]func(json.RawMessage))
+	for instrument := range wanted {
+		if !c.watched[instrument] && instrument != c.subscribedInstrument {
+			added[c.tickerChannel(instrument)] = c.handleTicker
+		}
+	}
+
+	if err := c.unsubscribe(stale...); err != nil {
+		log.Printf("Failed to unsubscribe from tickers: %v", err)
+	}
+	if err := c.subscribe(added); err != nil {
+		log.Printf("Failed to subscribe to tickers: %v", err)
+	}
+	c.watched = wanted
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+)
+
+// tradeHistorySize bounds the rolling trade history kept per instrument
+const tradeHistorySize = 200
+
+// tradeHistory holds the latest trades of one instrument, newest first
+type tradeHistory struct {
+	instrument string
+	trades     []models.Trade
+}
+
+// add prepends new trades, which Deribit sends oldest first
+func (h *tradeHistory) add(instrument string, trades []models.Trade) {
+	if h.instrument != instrument {
+		h.instrument = instrument
+		h.trades = nil
+	}
+
+	merged := make([]models.Trade, 0, min(len(trades)+len(h.trades), tradeHistorySize))
+	for i := len(trades) - 1; i >= 0 && len(merged) < tradeHistorySize; i-- {
+		merged = append(merged, trades[i])
+	}
+	for _, trade := range h.trades {
+		if len(merged) == tradeHistorySize {
+			break
+		}
+		merged = append(merged, trade)
+	}
+	h.trades = merged
+}
+
+func (c *DeribitClient) tradesChannel(instrument string) string {
+	return fmt.Sprintf("trades.%s.%s", instrument, c.Interval)
+}
+
+func (c *DeribitClient) handleTrades(data json.RawMessage) {
+	var deribitTrades []models.DeribitTrade
+	if err := json.Unmarshal(data, &deribitTrades); err != nil {
+		log.Printf("Failed to unmarshal trades: %v", err)
+		return
+	}
+
+	instrument := c.Instrument()
+	trades := make([]models.Trade, 0, len(deribitTrades))
+	for i := range deribitTrades {
+		// Prints for an instrument we switched away from are dropped
+		if deribitTrades[i].InstrumentName != instrument {
+			continue
+		}
+		trades = append(trades, deribitTrades[i].Trade())
+	}
+	if len(trades) == 0 {
+		return
+	}
+
+	// The history slice is rebuilt on every add, so publishing it is safe
+	c.trades.add(instrument, trades)
+	c.Store.Publish(instrument, marketdata.KindTrades, c.trades.trades)
+}
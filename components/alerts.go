@@ -0,0 +1,365 @@
+package components
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/adityanagar10/trader/alerts"
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Text fields of the alerts panel
+const (
+	alertFieldNone = iota
+	alertFieldThreshold
+	alertFieldWebhook
+)
+
+const (
+	toastDuration = 6 * time.Second
+	toastFade     = time.Second
+	toastMax      = 5
+	toastWidth    = 340
+)
+
+// alertModes are the modes offered, in the order of the mode picker
+var alertModes = []alerts.Mode{alerts.ModeOnce, alerts.ModeRearm}
+
+// AlertsPanel adds and lists the alerts of an engine, with the recent
+// firings below. New alerts are for the focused instrument.
+type AlertsPanel struct {
+	engine     *alerts.Engine
+	instrument string
+
+	metricPicker    *Dropdown
+	directionPicker *Dropdown
+	modePicker      *Dropdown
+
+	// editing is the text field taking keyboard input
+	editing   int
+	threshold string
+	webhook   string
+	err       string
+}
+
+func NewAlertsPanel(engine *alerts.Engine, focused string, font rl.Font) *AlertsPanel {
+	metrics := make([]string, len(alerts.Metrics))
+	for i, metric := range alerts.Metrics {
+		metrics[i] = metric.Label()
+	}
+	return &AlertsPanel{
+		engine:          engine,
+		instrument:      focused,
+		metricPicker:    NewDropdown(0, 0, 210, metrics, "", font),
+		directionPicker: NewDropdown(0, 0, 90, []string{"Above", "Below"}, "", font),
+		modePicker:      NewDropdown(0, 0, 100, []string{"Once", "Re-arm"}, "", font),
+		webhook:         engine.WebhookURL(),
+	}
+}
+
+// Focus sets the instrument new alerts are for
+func (p *AlertsPanel) Focus(instrument string) {
+	p.instrument = instrument
+}
+
+// add creates an alert from the form
+func (p *AlertsPanel) add() {
+	alert := alerts.Alert{
+		Instrument: p.instrument,
+		Metric:     alerts.Metrics[p.metricPicker.SelectedIndex],
+		Above:      p.directionPicker.SelectedIndex == 0,
+		Mode:       alertModes[p.modePicker.SelectedIndex],
+	}
+	if alert.Metric == alerts.MetricConnection {
+		alert.Instrument = ""
+	} else {
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(p.threshold), 64)
+		if err != nil {
+			p.err = "Threshold is not a number"
+			return
+		}
+		alert.Threshold = threshold
+	}
+	p.engine.Add(alert)
+	p.err = ""
+	p.threshold = ""
+	p.editing = alertFieldNone
+}
+
+// editText applies this frame's typing to the field being edited, and
+// returns true when Enter finishes it
+func (p *AlertsPanel) editText() bool {
+	field := &p.threshold
+	if p.editing == alertFieldWebhook {
+		field = &p.webhook
+	}
+	for r := models.GetCharPressed(); r != 0; r = models.GetCharPressed() {
+		if unicode.IsPrint(rune(r)) {
+			*field += string(rune(r))
+		}
+	}
+	if (models.IsKeyPressed(rl.KeyBackspace) || models.IsKeyPressedRepeat(rl.KeyBackspace)) && *field != "" {
+		*field = trimLastRune(*field)
+	}
+	return models.IsKeyPressed(rl.KeyEnter) || models.IsKeyPressed(rl.KeyKpEnter)
+}
+
+// finishEditing commits the field being edited
+func (p *AlertsPanel) finishEditing() {
+	switch p.editing {
+	case alertFieldThreshold:
+		p.add()
+	case alertFieldWebhook:
+		p.engine.SetWebhookURL(strings.TrimSpace(p.webhook))
+	}
+	p.editing = alertFieldNone
+}
+
+// drawTextField draws a text box, with a cursor while it is edited, and
+// the placeholder while it is empty
+func drawTextField(font rl.Font, rect rl.Rectangle, text, placeholder string, editing bool) {
+	rl.DrawRectangleRec(rect, colors.ColorPanelBg)
+	border := colors.ColorBorder
+	if editing {
+		border = colors.ColorSubtext
+	}
+	rl.DrawRectangleLinesEx(rect, 1, border)
+
+	position := rl.Vector2{X: rect.X + 6, Y: rect.Y + 5}
+	if text == "" && !editing {
+		rl.DrawTextEx(font, placeholder, position, 16, 1, colors.ColorSubtext)
+		return
+	}
+
+	// Long text drops its start to keep the end in view
+	size := rl.MeasureTextEx(font, text, 16, 1)
+	for text != "" && size.X > rect.Width-16 {
+		text = text[1:]
+		size = rl.MeasureTextEx(font, text, 16, 1)
+	}
+	rl.DrawTextEx(font, text, position, 16, 1, colors.ColorText)
+	if editing && time.Now().UnixMilli()/500%2 == 0 {
+		cursorX := position.X + size.X + 1
+		rl.DrawLine(int32(cursorX), int32(rect.Y+4), int32(cursorX), int32(rect.Y+rect.Height-4), colors.ColorText)
+	}
+}
+
+func (p *AlertsPanel) Render(w *models.Window) {
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 40
+	width := w.Rect.Width - w.Padding*2
+	mousePos := rl.GetMousePosition()
+	// A click on an open picker's options is not for what lies under it
+	pickersOpen := p.metricPicker.IsOpen || p.directionPicker.IsOpen || p.modePicker.IsOpen
+	clicked := models.IsMouseButtonPressed(rl.MouseLeftButton) && !pickersOpen
+	button := func(label string, bx, by float32) (rl.Rectangle, bool) {
+		size := rl.MeasureTextEx(w.Font, label, 14, 1)
+		rect := rl.Rectangle{X: bx, Y: by, Width: size.X + 12, Height: 20}
+		rl.DrawRectangleRec(rect, colors.ColorHeaderBg)
+		rl.DrawRectangleLinesEx(rect, 1, colors.ColorBorder)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: rect.X + 6, Y: rect.Y + 3}, 14, 1, colors.ColorText)
+		return rect, clicked && rl.CheckCollisionPointRec(mousePos, rect)
+	}
+
+	if p.editing != alertFieldNone && p.editText() {
+		p.finishEditing()
+	}
+
+	// Form for a new alert: instrument, metric, direction, threshold, mode
+	connection := alerts.Metrics[p.metricPicker.SelectedIndex] == alerts.MetricConnection
+	formX := x
+	label := p.instrument
+	if connection {
+		label = "Any"
+	}
+	rl.DrawTextEx(w.Font, label, rl.Vector2{X: formX, Y: y + 5}, 16, 1, colors.ColorText)
+	formX += max(rl.MeasureTextEx(w.Font, label, 16, 1).X+10, 150)
+
+	p.metricPicker.Rect.X, p.metricPicker.Rect.Y = formX, y
+	formX += p.metricPicker.Rect.Width + 6
+	p.directionPicker.Rect.X, p.directionPicker.Rect.Y = formX, y
+	formX += p.directionPicker.Rect.Width + 6
+	thresholdRect := rl.Rectangle{X: formX, Y: y, Width: 110, Height: 26}
+	formX += thresholdRect.Width + 6
+	p.modePicker.Rect.X, p.modePicker.Rect.Y = formX, y
+	formX += p.modePicker.Rect.Width + 6
+
+	if !connection {
+		drawTextField(w.Font, thresholdRect, p.threshold, "Threshold", p.editing == alertFieldThreshold)
+	}
+	if _, ok := button("Add", formX, y+3); ok {
+		p.editing = alertFieldNone
+		p.add()
+	}
+
+	// Pickers take the mouse first and are drawn last to open over the list
+	p.metricPicker.Update()
+	p.modePicker.Update()
+	defer p.metricPicker.Draw()
+	defer p.modePicker.Draw()
+	if !connection {
+		p.directionPicker.Update()
+		defer p.directionPicker.Draw()
+	}
+	y += 34
+
+	// Webhook fired alerts are POSTed to, saved when the field is left
+	rl.DrawTextEx(w.Font, "Webhook", rl.Vector2{X: x, Y: y + 5}, 16, 1, colors.ColorSubtext)
+	webhookRect := rl.Rectangle{X: x + 80, Y: y, Width: min(width-80, 480), Height: 26}
+	drawTextField(w.Font, webhookRect, p.webhook, "None, e.g. http://localhost:8080/alerts", p.editing == alertFieldWebhook)
+	y += 34
+
+	if clicked {
+		field := alertFieldNone
+		switch {
+		case !connection && rl.CheckCollisionPointRec(mousePos, thresholdRect):
+			field = alertFieldThreshold
+		case rl.CheckCollisionPointRec(mousePos, webhookRect):
+			field = alertFieldWebhook
+		}
+		if field != p.editing {
+			// Leaving the webhook saves it, leaving the threshold keeps it
+			if p.editing == alertFieldWebhook {
+				p.finishEditing()
+			}
+			p.editing = field
+		}
+	}
+
+	if p.err != "" {
+		rl.DrawTextEx(w.Font, p.err, rl.Vector2{X: x, Y: y}, 14, 1, colors.ColorRed)
+		y += 20
+	}
+
+	// Alerts, each with its state and buttons to re-arm or remove it
+	list := p.engine.Alerts()
+	rl.DrawRectangle(int32(x), int32(y), int32(width), 24, colors.ColorHeaderBg)
+	rl.DrawTextEx(w.Font, fmt.Sprintf("Alerts (%d)", len(list)), rl.Vector2{X: x + 6, Y: y + 4}, 16, 1, colors.ColorText)
+	y += 28
+	if len(list) == 0 {
+		rl.DrawTextEx(w.Font, "No alerts", rl.Vector2{X: x + 6, Y: y}, 14, 1, colors.ColorSubtext)
+		y += 20
+	}
+	for _, alert := range list {
+		state, stateColor := "Armed", colors.ColorGreen
+		if !alert.Armed {
+			state, stateColor = "Off", colors.ColorSubtext
+		}
+		rl.DrawTextEx(w.Font, state, rl.Vector2{X: x + 6, Y: y + 3}, 14, 1, stateColor)
+		rl.DrawTextEx(w.Font, alert.Describe(), rl.Vector2{X: x + 60, Y: y + 3}, 14, 1, colors.ColorText)
+
+		detail := "once"
+		if alert.Mode == alerts.ModeRearm {
+			detail = "re-arms"
+		}
+		if !alert.FiredAt.IsZero() {
+			detail += ", fired " + alert.FiredAt.Local().Format("Jan 2 15:04:05")
+		}
+		detailSize := rl.MeasureTextEx(w.Font, detail, 14, 1)
+		rl.DrawTextEx(w.Font, detail, rl.Vector2{X: x + width - 110 - detailSize.X, Y: y + 3}, 14, 1, colors.ColorSubtext)
+
+		if _, ok := button("x", x+width-22, y); ok {
+			p.engine.Remove(alert.ID)
+		}
+		if !alert.Armed {
+			if _, ok := button("Re-arm", x+width-90, y); ok {
+				p.engine.Rearm(alert.ID)
+			}
+		}
+		y += 24
+	}
+	y += 8
+
+	// Recent firings, newest first, as far as the window reaches
+	rl.DrawRectangle(int32(x), int32(y), int32(width), 24, colors.ColorHeaderBg)
+	rl.DrawTextEx(w.Font, "Recent", rl.Vector2{X: x + 6, Y: y + 4}, 16, 1, colors.ColorText)
+	y += 28
+	bottom := w.Rect.Y + w.Rect.Height - w.Padding
+	for _, notification := range p.engine.Log() {
+		if y+18 > bottom {
+			break
+		}
+		rl.DrawTextEx(w.Font, notification.Time.Local().Format("15:04:05"), rl.Vector2{X: x + 6, Y: y}, 14, 1, colors.ColorSubtext)
+		rl.DrawTextEx(w.Font, notification.Message, rl.Vector2{X: x + 80, Y: y}, 14, 1, colors.ColorText)
+		y += 18
+	}
+}
+
+// toast is a notification on screen since shown
+type toast struct {
+	notification alerts.Notification
+	shown        time.Time
+}
+
+// Toasts shows fired alerts in the bottom right corner of the screen for
+// a few seconds each, newest at the bottom. Clicking one dismisses it.
+type Toasts struct {
+	font  rl.Font
+	items []toast
+}
+
+func NewToasts(font rl.Font) *Toasts {
+	return &Toasts{font: font}
+}
+
+func (t *Toasts) Add(notifications ...alerts.Notification) {
+	now := time.Now()
+	for _, notification := range notifications {
+		t.items = append(t.items, toast{notification: notification, shown: now})
+	}
+	// Past the limit the oldest make way
+	if len(t.items) > toastMax {
+		t.items = t.items[len(t.items)-toastMax:]
+	}
+}
+
+// Draw draws the toasts over everything else, call it after the windows
+func (t *Toasts) Draw() {
+	now := time.Now()
+	kept := t.items[:0]
+	for _, item := range t.items {
+		if now.Sub(item.shown) < toastDuration {
+			kept = append(kept, item)
+		}
+	}
+	t.items = kept
+
+	mousePos := rl.GetMousePosition()
+	x := float32(rl.GetScreenWidth()) - toastWidth - 10
+	y := float32(rl.GetScreenHeight()) - 30
+	for i := len(t.items) - 1; i >= 0; i-- {
+		item := t.items[i]
+		rect := rl.Rectangle{X: x, Y: y - 52, Width: toastWidth, Height: 48}
+		y = rect.Y - 6
+
+		if models.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mousePos, rect) {
+			t.items = append(t.items[:i], t.items[i+1:]...)
+			continue
+		}
+
+		// Fade out over the last second
+		alpha := float32(1)
+		if left := toastDuration - now.Sub(item.shown); left < toastFade {
+			alpha = float32(left) / float32(toastFade)
+		}
+		accent := colors.ColorOrange
+		if item.notification.Metric == alerts.MetricConnection {
+			accent = colors.ColorRed
+		}
+
+		rl.DrawRectangleRec(rect, rl.Fade(colors.ColorHeaderBg, alpha))
+		rl.DrawRectangleLinesEx(rect, 1, rl.Fade(colors.ColorBorder, alpha))
+		rl.DrawRectangleRec(rl.Rectangle{X: rect.X, Y: rect.Y, Width: 4, Height: rect.Height}, rl.Fade(accent, alpha))
+		title := "Alert  " + item.notification.Time.Local().Format("15:04:05")
+		rl.DrawTextEx(t.font, title, rl.Vector2{X: rect.X + 12, Y: rect.Y + 6}, 14, 1, rl.Fade(accent, alpha))
+
+		rl.BeginScissorMode(int32(rect.X), int32(rect.Y), int32(rect.Width-6), int32(rect.Height))
+		rl.DrawTextEx(t.font, item.notification.Message, rl.Vector2{X: rect.X + 12, Y: rect.Y + 26}, 14, 1, rl.Fade(colors.ColorText, alpha))
+		rl.EndScissorMode()
+	}
+}
@@ -0,0 +1,242 @@
+package components
+
+import (
+	"fmt"
+	"math"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// depthZoomLevels are how far from mid the depth chart reaches, as a
+// fraction of the mid price
+var depthZoomLevels = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.02, 0.05, 0.1}
+
+// depthPoint is a price level with the size and notional resting at it
+// or better
+type depthPoint struct {
+	price    float64
+	size     float64
+	notional float64
+}
+
+// DepthChart plots cumulative bid and ask size around the mid. It reads
+// the same order book feed as the order book window.
+type DepthChart struct {
+	zoom int
+}
+
+func NewDepthChart() *DepthChart {
+	return &DepthChart{zoom: 3}
+}
+
+// cumulativeDepth sums levels from the best price outwards
+func cumulativeDepth(levels [][]float64, spec *models.Instrument) []depthPoint {
+	points := make([]depthPoint, 0, len(levels))
+	size, notional := 0.0, 0.0
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		size += level[1]
+		notional += spec.Notional(level[1], level[0])
+		points = append(points, depthPoint{price: level[0], size: size, notional: notional})
+	}
+	return points
+}
+
+// depthAt returns the cumulative depth available at price, zero before
+// the best level. bids are sorted down from the best bid, asks up.
+func depthAt(points []depthPoint, price float64, bids bool) depthPoint {
+	result := depthPoint{price: price}
+	for _, point := range points {
+		if (bids && point.price < price) || (!bids && point.price > price) {
+			break
+		}
+		result.size, result.notional = point.size, point.notional
+	}
+	return result
+}
+
+func (d *DepthChart) Render(w *models.Window) {
+	orderBook, ok := w.Data.(*models.OrderBookResult)
+	if !ok || orderBook == nil {
+		drawPlaceholder(w, "Loading order book...")
+		return
+	}
+	if orderBook.Resyncing {
+		drawPlaceholder(w, "Resyncing order book...")
+		return
+	}
+	if len(orderBook.Bids) == 0 || len(orderBook.Asks) == 0 {
+		drawPlaceholder(w, "Waiting for both sides of the book...")
+		return
+	}
+
+	contentX := w.Rect.X + w.Padding
+	contentY := w.Rect.Y + 30
+	contentWidth := w.Rect.Width - w.Padding*2
+	contentHeight := w.Rect.Height - 30 - w.Padding
+
+	// Zoom control: -/+ buttons on the header row, or the mouse wheel
+	mousePos := rl.GetMousePosition()
+	clicked := func(rect rl.Rectangle) bool {
+		return models.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mousePos, rect)
+	}
+
+	zoomText := fmt.Sprintf("±%.2f%%", depthZoomLevels[d.zoom]*100)
+	zoomSize := rl.MeasureTextEx(w.Font, zoomText, 14, 1)
+	plusRect := rl.Rectangle{X: contentX + contentWidth - 18, Y: contentY, Width: 18, Height: 18}
+	minusRect := rl.Rectangle{X: plusRect.X - zoomSize.X - 30, Y: contentY, Width: 18, Height: 18}
+	for _, button := range []struct {
+		rect  rl.Rectangle
+		label string
+	}{{minusRect, "-"}, {plusRect, "+"}} {
+		rl.DrawRectangleRec(button.rect, colors.ColorHeaderBg)
+		rl.DrawRectangleLinesEx(button.rect, 1, colors.ColorBorder)
+		rl.DrawTextEx(w.Font, button.label, rl.Vector2{X: button.rect.X + 5, Y: button.rect.Y + 1}, 16, 1, colors.ColorText)
+	}
+	rl.DrawTextEx(w.Font, zoomText, rl.Vector2{X: minusRect.X + 24, Y: contentY + 2}, 14, 1, colors.ColorSubtext)
+	if clicked(minusRect) {
+		d.zoom = max(0, d.zoom-1)
+	}
+	if clicked(plusRect) {
+		d.zoom = min(len(depthZoomLevels)-1, d.zoom+1)
+	}
+
+	plot := rl.Rectangle{
+		X:      contentX,
+		Y:      contentY + 24,
+		Width:  contentWidth - graphAxisWidth,
+		Height: contentHeight - 24 - graphTimeAxisHeight,
+	}
+	if plot.Width < 50 || plot.Height < 30 {
+		return
+	}
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		// Scrolling up zooms in towards the mid
+		if wheel := models.GetMouseWheelMove(); wheel > 0 {
+			d.zoom = max(0, d.zoom-1)
+		} else if wheel < 0 {
+			d.zoom = min(len(depthZoomLevels)-1, d.zoom+1)
+		}
+	}
+
+	bids := cumulativeDepth(orderBook.Bids, w.Spec)
+	asks := cumulativeDepth(orderBook.Asks, w.Spec)
+	mid := (bids[0].price + asks[0].price) / 2
+	low := mid * (1 - depthZoomLevels[d.zoom])
+	high := mid * (1 + depthZoomLevels[d.zoom])
+
+	// Size scale covers both sides out to the edges of the range
+	maxSize := math.Max(depthAt(bids, low, true).size, depthAt(asks, high, false).size)
+	if maxSize == 0 {
+		maxSize = 1
+	}
+
+	priceX := func(price float64) float32 {
+		return plot.X + float32((price-low)/(high-low))*plot.Width
+	}
+	sizeY := func(size float64) float32 {
+		return plot.Y + plot.Height - float32(size/maxSize)*plot.Height*0.95
+	}
+
+	rl.DrawRectangleRec(plot, colors.ColorChartBg)
+	rl.BeginScissorMode(int32(plot.X), int32(plot.Y), int32(plot.Width), int32(plot.Height))
+	d.drawSide(bids, mid, low, priceX, sizeY, plot, colors.ColorGreen)
+	d.drawSide(asks, mid, high, priceX, sizeY, plot, colors.ColorRed)
+	midX := priceX(mid)
+	rl.DrawLine(int32(midX), int32(plot.Y), int32(midX), int32(plot.Y+plot.Height), colors.ColorBorder)
+	rl.EndScissorMode()
+	w.ClipContent()
+
+	// Size axis on the right, price axis at the bottom
+	for i := 1; i <= 4; i++ {
+		size := maxSize * float64(i) / 4
+		y := sizeY(size)
+		rl.DrawTextEx(w.Font, w.Spec.FormatAmount(size),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: y - 7}, 14, 1, colors.ColorSubtext)
+	}
+	for _, price := range []float64{low, mid, high} {
+		label := w.Spec.FormatPrice(price)
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		x := min(max(priceX(price)-labelSize.X/2, plot.X), plot.X+plot.Width-labelSize.X)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: x, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+
+	spread := asks[0].price - bids[0].price
+	rl.DrawTextEx(w.Font, fmt.Sprintf("Mid %s  Spread %s", w.Spec.FormatPrice(mid), w.Spec.FormatPrice(spread)),
+		rl.Vector2{X: contentX, Y: contentY + 2}, 14, 1, colors.ColorText)
+
+	if !rl.CheckCollisionPointRec(mousePos, plot) {
+		return
+	}
+
+	// Hover readout of the cumulative depth at the cursor's price
+	price := low + float64((mousePos.X-plot.X)/plot.Width)*(high-low)
+	point, color := depthAt(bids, price, true), colors.ColorGreen
+	if price > mid {
+		point, color = depthAt(asks, price, false), colors.ColorRed
+	}
+	y := sizeY(point.size)
+	rl.DrawLine(int32(mousePos.X), int32(plot.Y), int32(mousePos.X), int32(plot.Y+plot.Height), colors.ColorSubtext)
+	rl.DrawCircleV(rl.Vector2{X: mousePos.X, Y: y}, 4, color)
+
+	lines := []string{
+		fmt.Sprintf("Price    %s (%+.3f%%)", w.Spec.FormatPrice(price), (price-mid)/mid*100),
+		fmt.Sprintf("Cum size %s", w.Spec.FormatAmount(point.size)),
+		fmt.Sprintf("Notional %s", models.FormatNotional(point.notional)),
+	}
+	boxWidth := float32(0)
+	for _, line := range lines {
+		boxWidth = max(boxWidth, rl.MeasureTextEx(w.Font, line, 14, 1).X)
+	}
+	box := rl.Rectangle{X: mousePos.X + 12, Y: plot.Y + 6, Width: boxWidth + 12, Height: float32(len(lines))*16 + 8}
+	if box.X+box.Width > plot.X+plot.Width {
+		box.X = mousePos.X - 12 - box.Width
+	}
+	rl.DrawRectangleRec(box, colors.ColorHeaderBg)
+	rl.DrawRectangleLinesEx(box, 1, colors.ColorBorder)
+	for i, line := range lines {
+		rl.DrawTextEx(w.Font, line, rl.Vector2{X: box.X + 6, Y: box.Y + 4 + float32(i)*16}, 14, 1, colors.ColorText)
+	}
+}
+
+// drawSide draws one side of the book as a filled step curve from the
+// mid out to edge
+func (d *DepthChart) drawSide(points []depthPoint, mid, edge float64, priceX func(float64) float32, sizeY func(float64) float32, plot rl.Rectangle, color rl.Color) {
+	fill := rl.NewColor(color.R, color.G, color.B, 50)
+	bottom := plot.Y + plot.Height
+	outwards := func(price float64) bool {
+		if edge < mid {
+			return price < edge
+		}
+		return price > edge
+	}
+
+	// Nothing rests between the mid and the best level
+	previous := rl.Vector2{X: priceX(mid), Y: bottom}
+	for i, point := range points {
+		x := priceX(point.price)
+		y := sizeY(point.size)
+
+		// The size holds until the next level, or out to the edge after
+		// the last one
+		next := edge
+		if i+1 < len(points) && !outwards(points[i+1].price) {
+			next = points[i+1].price
+		}
+		nextX := priceX(next)
+
+		rl.DrawLineEx(previous, rl.Vector2{X: x, Y: previous.Y}, 1.5, color)
+		rl.DrawLineEx(rl.Vector2{X: x, Y: previous.Y}, rl.Vector2{X: x, Y: y}, 1.5, color)
+		rl.DrawRectangleRec(rl.Rectangle{X: min(x, nextX), Y: y, Width: float32(math.Abs(float64(nextX - x))), Height: bottom - y}, fill)
+
+		previous = rl.Vector2{X: x, Y: y}
+		if outwards(point.price) || next == edge {
+			break
+		}
+	}
+	rl.DrawLineEx(previous, rl.Vector2{X: priceX(edge), Y: previous.Y}, 1.5, color)
+}
@@ -2,6 +2,7 @@ package components
 
 import (
 	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/models"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -43,7 +44,7 @@ func (d *Dropdown) Update() {
 	mousePos := rl.GetMousePosition()
 
 	// Check if clicked on dropdown
-	if rl.CheckCollisionPointRec(mousePos, d.Rect) && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+	if rl.CheckCollisionPointRec(mousePos, d.Rect) && models.IsMouseButtonPressed(rl.MouseLeftButton) {
 		d.IsOpen = !d.IsOpen
 	} else if d.IsOpen {
 		// Check if clicked on an option
@@ -55,7 +56,7 @@ func (d *Dropdown) Update() {
 				Height: 26,
 			}
 
-			if rl.CheckCollisionPointRec(mousePos, optionRect) && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+			if rl.CheckCollisionPointRec(mousePos, optionRect) && models.IsMouseButtonPressed(rl.MouseLeftButton) {
 				if d.SelectedIndex != i {
 					d.SelectedIndex = i
 					if d.OnChangeHandler != nil {
@@ -68,7 +69,7 @@ func (d *Dropdown) Update() {
 		}
 
 		// Close if clicked elsewhere
-		if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+		if models.IsMouseButtonPressed(rl.MouseLeftButton) {
 			d.IsOpen = false
 		}
 	}
@@ -0,0 +1,232 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// fundingSettlementHour is the hour, in UTC, of Deribit's daily
+// settlement. Perpetual funding accrues continuously and is settled into
+// session P&L then.
+const fundingSettlementHour = 8
+
+const hoursPerYear = 24 * 365
+
+// fundingRanges are the spans of history the chart can show
+var fundingRanges = []struct {
+	label  string
+	length time.Duration
+}{
+	{"3D", 3 * 24 * time.Hour},
+	{"1W", 7 * 24 * time.Hour},
+	{"2W", 14 * 24 * time.Hour},
+	{"30D", 30 * 24 * time.Hour},
+}
+
+// nextSettlement is the next daily settlement after now
+func nextSettlement(now time.Time) time.Time {
+	now = now.UTC()
+	settlement := time.Date(now.Year(), now.Month(), now.Day(), fundingSettlementHour, 0, 0, 0, time.UTC)
+	if !settlement.After(now) {
+		settlement = settlement.AddDate(0, 0, 1)
+	}
+	return settlement
+}
+
+// realizedFundingPct annualizes the hourly funding paid since since, in
+// percent, zero without history in the range
+func realizedFundingPct(history []models.FundingRate, since time.Time) float64 {
+	total, hours := 0.0, 0
+	for _, rate := range history {
+		if rate.Time().After(since) {
+			total += rate.Interest1h
+			hours++
+		}
+	}
+	if hours == 0 {
+		return 0
+	}
+	return total / float64(hours) * hoursPerYear * 100
+}
+
+// FundingPanel compares the carry of perpetuals: predicted and realized
+// funding, annualized, and a chart of the realized 8 hour rate of the one
+// picked. History is loaded by the client, the predicted rates come from
+// the perpetuals' tickers.
+type FundingPanel struct {
+	store      *marketdata.Store
+	perpetuals []string
+	// selected is the perpetual charted, span the index of its range
+	selected int
+	span     int
+}
+
+func NewFundingPanel(store *marketdata.Store, perpetuals []string) *FundingPanel {
+	return &FundingPanel{
+		store:      store,
+		perpetuals: perpetuals,
+		span:       1,
+	}
+}
+
+func (f *FundingPanel) Render(w *models.Window) {
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 35
+	width := w.Rect.Width - w.Padding*2
+	mousePos := rl.GetMousePosition()
+	clicked := models.IsMouseButtonPressed(rl.MouseLeftButton)
+	now := time.Now()
+	since := now.Add(-fundingRanges[f.span].length)
+
+	columns := []struct {
+		title string
+		x     float32
+	}{
+		{"Perpetual", 0}, {"Current", 190}, {"8h", 280}, {"8h ann.", 370}, {"Realized " + fundingRanges[f.span].label, 460},
+	}
+	for _, column := range columns {
+		rl.DrawTextEx(w.Font, column.title, rl.Vector2{X: x + column.x, Y: y}, 16, 1, colors.ColorSubtext)
+	}
+	y += 24
+
+	// One row per perpetual, clicking one charts it
+	for i, perpetual := range f.perpetuals {
+		rowRect := rl.Rectangle{X: w.Rect.X + 1, Y: y, Width: w.Rect.Width - 2, Height: 20}
+		if i == f.selected {
+			rl.DrawRectangleRec(rowRect, colors.ColorHeaderBg)
+		}
+		if clicked && rl.CheckCollisionPointRec(mousePos, rowRect) {
+			f.selected = i
+		}
+
+		cells := []string{perpetual, "-", "-", "-", "-"}
+		cellColors := []rl.Color{colors.ColorText, colors.ColorText, colors.ColorText, colors.ColorText, colors.ColorText}
+		if ticker, _ := f.store.Ticker(perpetual); ticker != nil {
+			cells[1] = fmt.Sprintf("%+.4f%%", ticker.CurrentFunding*100)
+			cells[2] = fmt.Sprintf("%+.4f%%", ticker.Funding8h*100)
+			cells[3] = fmt.Sprintf("%+.2f%%", ticker.AnnualizedFundingPct())
+			cellColors[1], cellColors[2], cellColors[3] = signColor(ticker.CurrentFunding), signColor(ticker.Funding8h), signColor(ticker.Funding8h)
+		}
+		if history, _ := f.store.FundingHistory(perpetual); len(history) > 0 {
+			realized := realizedFundingPct(history, since)
+			cells[4] = fmt.Sprintf("%+.2f%%", realized)
+			cellColors[4] = signColor(realized)
+		}
+		for j, cell := range cells {
+			rl.DrawTextEx(w.Font, cell, rl.Vector2{X: x + columns[j].x, Y: y + 2}, 16, 1, cellColors[j])
+		}
+		y += 20
+	}
+	y += 8
+
+	// Countdown to the daily settlement on the left, chart range on the right
+	remaining := nextSettlement(now).Sub(now).Truncate(time.Second)
+	countdown := fmt.Sprintf("Funding settles 08:00 UTC in %02d:%02d:%02d",
+		int(remaining.Hours()), int(remaining.Minutes())%60, int(remaining.Seconds())%60)
+	rl.DrawTextEx(w.Font, countdown, rl.Vector2{X: x, Y: y + 3}, 16, 1, colors.ColorText)
+	labels := make([]string, len(fundingRanges))
+	for i, span := range fundingRanges {
+		labels[i] = span.label
+	}
+	tabsWidth := float32(4 * 50)
+	if index, _ := drawTabs(w.Font, x+width-tabsWidth, y, tabsWidth, labels, func(i int) bool {
+		return i == f.span
+	}); index >= 0 {
+		f.span = index
+	}
+	y += 28
+
+	plot := rl.Rectangle{
+		X:      x,
+		Y:      y,
+		Width:  width - graphAxisWidth,
+		Height: w.Rect.Y + w.Rect.Height - w.Padding - graphTimeAxisHeight - y,
+	}
+	if plot.Width < 50 || plot.Height < 40 {
+		return
+	}
+
+	history, _ := f.store.FundingHistory(f.perpetuals[f.selected])
+	var rates []models.FundingRate
+	for _, rate := range history {
+		if rate.Time().After(since) {
+			rates = append(rates, rate)
+		}
+	}
+	rl.DrawRectangleRec(plot, colors.ColorChartBg)
+	if len(rates) < 2 {
+		rl.DrawTextEx(w.Font, "Loading funding history...", rl.Vector2{X: plot.X + 8, Y: plot.Y + 8}, 16, 1, colors.ColorSubtext)
+		return
+	}
+
+	// The 8 hour rate in percent, scaled around zero
+	low, high := 0.0, 0.0
+	for _, rate := range rates {
+		low, high = math.Min(low, rate.Interest8h*100), math.Max(high, rate.Interest8h*100)
+	}
+	padding := math.Max((high-low)*0.1, 0.001)
+	low, high = low-padding, high+padding
+	start, end := since.UnixMilli(), now.UnixMilli()
+	toScreen := func(timestamp int64, value float64) rl.Vector2 {
+		return rl.Vector2{
+			X: plot.X + float32(float64(timestamp-start)/float64(end-start))*plot.Width,
+			Y: plot.Y + plot.Height - float32((value-low)/(high-low))*plot.Height,
+		}
+	}
+
+	zeroY := toScreen(start, 0).Y
+	rl.DrawLine(int32(plot.X), int32(zeroY), int32(plot.X+plot.Width), int32(zeroY), colors.ColorBorder)
+	for i := 1; i < len(rates); i++ {
+		from := toScreen(rates[i-1].Timestamp, rates[i-1].Interest8h*100)
+		to := toScreen(rates[i].Timestamp, rates[i].Interest8h*100)
+		color := colors.ColorGreen
+		if rates[i].Interest8h < 0 {
+			color = colors.ColorRed
+		}
+		rl.DrawLineEx(from, to, 1.5, color)
+	}
+
+	for i := 0; i <= 4; i++ {
+		value := low + (high-low)*float64(i)/4
+		labelY := toScreen(start, value).Y
+		rl.DrawTextEx(w.Font, fmt.Sprintf("%+.4f%%", value),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: labelY - 7}, 14, 1, colors.ColorSubtext)
+
+		timestamp := start + (end-start)*int64(i)/4
+		label := time.UnixMilli(timestamp).Local().Format("Jan 02 15:04")
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		labelX := min(max(toScreen(timestamp, low).X-labelSize.X/2, plot.X), plot.X+plot.Width-labelSize.X)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: labelX, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Hover readout of the nearest hour
+	if !rl.CheckCollisionPointRec(mousePos, plot) {
+		return
+	}
+	timestamp := start + int64(float64(mousePos.X-plot.X)/float64(plot.Width)*float64(end-start))
+	nearest := rates[0]
+	for _, rate := range rates {
+		if math.Abs(float64(rate.Timestamp-timestamp)) < math.Abs(float64(nearest.Timestamp-timestamp)) {
+			nearest = rate
+		}
+	}
+	point := toScreen(nearest.Timestamp, nearest.Interest8h*100)
+	rl.DrawLine(int32(point.X), int32(plot.Y), int32(point.X), int32(plot.Y+plot.Height), colors.ColorSubtext)
+	rl.DrawCircleV(point, 3, colors.ColorText)
+	label := fmt.Sprintf("%s  8h %+.4f%%  ann. %+.2f%%", nearest.Time().Local().Format("Jan 02 15:04"),
+		nearest.Interest8h*100, nearest.Interest8h*hoursPerYear/8*100)
+	size := rl.MeasureTextEx(w.Font, label, 14, 1)
+	box := rl.Rectangle{X: point.X + 10, Y: plot.Y + 6, Width: size.X + 12, Height: 20}
+	if box.X+box.Width > plot.X+plot.Width {
+		box.X = point.X - 10 - box.Width
+	}
+	rl.DrawRectangleRec(box, colors.ColorHeaderBg)
+	rl.DrawRectangleLinesEx(box, 1, colors.ColorBorder)
+	rl.DrawTextEx(w.Font, label, rl.Vector2{X: box.X + 6, Y: box.Y + 3}, 14, 1, colors.ColorText)
+}
@@ -0,0 +1,273 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/registry"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	futuresRowHeight = float32(20)
+	// futuresMinPlotHeight keeps room for the curve under the table
+	futuresMinPlotHeight = float32(120)
+)
+
+// futureCurrencies lists the currencies with dated futures
+func futureCurrencies(instruments []*models.Instrument) []string {
+	var currencies []string
+	for _, instrument := range instruments {
+		if instrument.Kind == "future" && !instrument.Perpetual() && !slices.Contains(currencies, instrument.BaseCurrency) {
+			currencies = append(currencies, instrument.BaseCurrency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// futuresCurve returns the perpetual and dated futures of a currency,
+// perpetual first, then by expiry. Currencies with both coin and USDC
+// settled futures keep the coin settled ones, which carry the dated curve.
+func futuresCurve(instruments []*models.Instrument, currency string) []*models.Instrument {
+	var curve []*models.Instrument
+	inverse := false
+	for _, instrument := range instruments {
+		if instrument.Kind == "future" && instrument.BaseCurrency == currency {
+			curve = append(curve, instrument)
+			inverse = inverse || instrument.Inverse()
+		}
+	}
+	if inverse {
+		curve = slices.DeleteFunc(curve, func(instrument *models.Instrument) bool {
+			return !instrument.Inverse()
+		})
+	}
+	sort.SliceStable(curve, func(i, j int) bool {
+		if curve[i].Perpetual() != curve[j].Perpetual() {
+			return curve[i].Perpetual()
+		}
+		return curve[i].ExpirationTimestamp < curve[j].ExpirationTimestamp
+	})
+	return curve
+}
+
+// FuturesCurve lists a currency's perpetual and dated futures with their
+// basis to the index, and plots mark price against time to expiry. The
+// futures come from the instrument registry and their prices from the
+// tickers followed through the visible handler. Clicking a row focuses it.
+type FuturesCurve struct {
+	store    *marketdata.Store
+	registry *registry.Registry
+
+	currency string
+	// focused is the instrument the other windows show
+	focused string
+	// currencies and the curve are rebuilt when the registry or currency
+	// change
+	version    uint64
+	currencies []string
+	curve      []*models.Instrument
+
+	// visible reports the listed futures
+	visible visibleReporter
+
+	onSelect func(instrument string)
+}
+
+func NewFuturesCurve(store *marketdata.Store, instruments *registry.Registry, currency, focused string) *FuturesCurve {
+	return &FuturesCurve{
+		store:    store,
+		registry: instruments,
+		currency: currency,
+		focused:  focused,
+	}
+}
+
+// SetOnSelectHandler sets the callback for when a row is clicked
+func (f *FuturesCurve) SetOnSelectHandler(handler func(string)) {
+	f.onSelect = handler
+}
+
+// SetOnVisibleHandler sets the callback for when the listed futures
+// change, used to follow their tickers
+func (f *FuturesCurve) SetOnVisibleHandler(handler func([]string)) {
+	f.visible.handler = handler
+}
+
+// Focus marks the instrument the other windows show
+func (f *FuturesCurve) Focus(instrument string) {
+	f.focused = instrument
+}
+
+// sync rebuilds the curve when the registry or currency changed, moving
+// to the first currency when the one shown is no longer listed
+func (f *FuturesCurve) sync() {
+	version := f.registry.Version()
+	if version == f.version && f.curve != nil {
+		return
+	}
+	f.version = version
+
+	all := f.registry.All()
+	f.currencies = futureCurrencies(all)
+	if len(f.currencies) > 0 && !slices.Contains(f.currencies, f.currency) {
+		f.currency = f.currencies[0]
+	}
+	f.curve = futuresCurve(all, f.currency)
+
+	names := make([]string, len(f.curve))
+	for i, instrument := range f.curve {
+		names[i] = instrument.InstrumentName
+	}
+	f.visible.report(names)
+}
+
+func (f *FuturesCurve) Render(w *models.Window) {
+	f.sync()
+	currencies := f.currencies
+	if len(currencies) == 0 {
+		drawPlaceholder(w, "Loading instruments...")
+		return
+	}
+	w.Title = fmt.Sprintf("deribit %s - Futures Curve", f.currency)
+
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 35
+	width := w.Rect.Width - w.Padding*2
+	mousePos := rl.GetMousePosition()
+	clicked := models.IsMouseButtonPressed(rl.MouseLeftButton)
+
+	index, height := drawTabs(w.Font, x, y, width, currencies, func(i int) bool {
+		return currencies[i] == f.currency
+	})
+	if index >= 0 && currencies[index] != f.currency {
+		f.currency = currencies[index]
+		f.curve = nil
+		f.sync()
+	}
+	y += height + 4
+
+	// Table of the curve
+	columns := []struct {
+		title string
+		x     float32
+	}{
+		{"Instrument", 0}, {"Days", 150}, {"Mark", 200}, {"Index", 290},
+		{"Basis", 380}, {"Basis %", 460}, {"Ann. %", 530},
+	}
+	for _, column := range columns {
+		rl.DrawTextEx(w.Font, column.title, rl.Vector2{X: x + column.x, Y: y}, 16, 1, colors.ColorSubtext)
+	}
+	y += futuresRowHeight + 4
+
+	type curvePrice struct {
+		days float64
+		mark float64
+	}
+	var prices []curvePrice
+	indexPrice := 0.0
+	now := time.Now()
+	for _, instrument := range f.curve {
+		ticker, _ := f.store.Ticker(instrument.InstrumentName)
+		rowRect := rl.Rectangle{X: w.Rect.X + 1, Y: y, Width: w.Rect.Width - 2, Height: futuresRowHeight}
+		hovered := rl.CheckCollisionPointRec(mousePos, rowRect)
+		if hovered || instrument.InstrumentName == f.focused {
+			rl.DrawRectangleRec(rowRect, colors.ColorHeaderBg)
+		}
+		if hovered && clicked && instrument.InstrumentName != f.focused && f.onSelect != nil {
+			f.onSelect(instrument.InstrumentName)
+		}
+
+		days := "-"
+		if expiry := instrument.Expiry(); !expiry.IsZero() {
+			days = fmt.Sprintf("%.1f", expiry.Sub(now).Hours()/24)
+		}
+		cells := []string{instrument.InstrumentName, days, "-", "-", "-", "-", "-"}
+		basisColor := colors.ColorText
+		if ticker != nil && ticker.IndexPrice > 0 {
+			cells[2] = instrument.FormatPrice(ticker.MarkPrice)
+			cells[3] = instrument.FormatPrice(ticker.IndexPrice)
+			cells[4] = fmt.Sprintf("%+.2f", ticker.Basis())
+			cells[5] = fmt.Sprintf("%+.3f%%", ticker.BasisPct())
+			basisColor = signColor(ticker.Basis())
+			if instrument.Perpetual() {
+				// Perpetuals carry through funding rather than to an expiry
+				cells[6] = fmt.Sprintf("%+.2f%% f", ticker.AnnualizedFundingPct())
+			} else {
+				cells[6] = fmt.Sprintf("%+.2f%%", ticker.AnnualizedBasisPct(instrument.Expiry()))
+			}
+
+			indexPrice = ticker.IndexPrice
+			prices = append(prices, curvePrice{days: math.Max(0, instrument.Expiry().Sub(now).Hours()/24), mark: ticker.MarkPrice})
+			if instrument.Perpetual() {
+				prices[len(prices)-1].days = 0
+			}
+		}
+		for i, cell := range cells {
+			color := colors.ColorText
+			if i >= 4 {
+				color = basisColor
+			}
+			rl.DrawTextEx(w.Font, cell, rl.Vector2{X: x + columns[i].x, Y: y + 2}, 16, 1, color)
+		}
+		y += futuresRowHeight
+	}
+
+	// Mark price against days to expiry, the index as the flat line the
+	// curve converges to
+	plot := rl.Rectangle{
+		X:      x,
+		Y:      y + 10,
+		Width:  width - graphAxisWidth,
+		Height: w.Rect.Y + w.Rect.Height - w.Padding - graphTimeAxisHeight - y - 10,
+	}
+	if len(prices) == 0 || plot.Height < futuresMinPlotHeight || plot.Width < 50 {
+		return
+	}
+
+	maxDays := 1.0
+	low, high := indexPrice, indexPrice
+	for _, price := range prices {
+		maxDays = math.Max(maxDays, price.days)
+		low, high = math.Min(low, price.mark), math.Max(high, price.mark)
+	}
+	padding := math.Max((high-low)*0.1, indexPrice*0.001)
+	low, high = low-padding, high+padding
+	toScreen := func(days, price float64) rl.Vector2 {
+		return rl.Vector2{
+			X: plot.X + float32(days/maxDays)*plot.Width,
+			Y: plot.Y + plot.Height - float32((price-low)/(high-low))*plot.Height,
+		}
+	}
+
+	rl.DrawRectangleRec(plot, colors.ColorChartBg)
+	indexY := toScreen(0, indexPrice).Y
+	rl.DrawLineEx(rl.Vector2{X: plot.X, Y: indexY}, rl.Vector2{X: plot.X + plot.Width, Y: indexY}, 1, colors.ColorSubtext)
+	rl.DrawTextEx(w.Font, "Index "+f.curve[0].FormatPrice(indexPrice), rl.Vector2{X: plot.X + 6, Y: indexY - 16}, 14, 1, colors.ColorSubtext)
+	for i, price := range prices {
+		point := toScreen(price.days, price.mark)
+		if i > 0 {
+			rl.DrawLineEx(toScreen(prices[i-1].days, prices[i-1].mark), point, 2, colors.ColorBlue)
+		}
+		rl.DrawCircleV(point, 3, colors.ColorBlue)
+	}
+
+	for i := 0; i <= 4; i++ {
+		price := low + (high-low)*float64(i)/4
+		labelY := toScreen(0, price).Y
+		rl.DrawTextEx(w.Font, fmt.Sprintf("%.0f", price),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: labelY - 7}, 14, 1, colors.ColorSubtext)
+		days := maxDays * float64(i) / 4
+		label := fmt.Sprintf("%.0fd", days)
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		labelX := min(max(toScreen(days, low).X-labelSize.X/2, plot.X), plot.X+plot.Width-labelSize.X)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: labelX, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+}
@@ -0,0 +1,509 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/adityanagar10/trader/candles"
+	"github.com/adityanagar10/trader/client"
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Bars requested when backfilling history, and the most kept
+	graphBackfillBars = 300
+	graphMaxBars      = 2000
+	// A failed backfill is retried after graphRetryMin, doubling up to
+	// graphRetryMax
+	graphRetryMin = time.Second
+	graphRetryMax = time.Minute
+
+	graphAxisWidth      = float32(80)
+	graphTimeAxisHeight = float32(20)
+	graphInfoHeight     = float32(30)
+	graphOscillatorPane = float32(80)
+)
+
+// backfillResult carries history loaded in the background to the render
+// loop. The history runs up to end.
+type backfillResult struct {
+	generation int
+	end        time.Time
+	candles    []models.Candle
+	err        error
+}
+
+// InstrumentGraph is a candlestick chart of the window's instrument at a
+// resolution picked in the window. It backfills history from Deribit and
+// keeps the last bar current from the live trades in the store. History
+// is fetched again whenever the connection goes live, so an outage is
+// filled from Deribit, and a failed fetch is retried.
+type InstrumentGraph struct {
+	client *client.DeribitClient
+	store  *marketdata.Store
+
+	instrument    string
+	resolution    candles.Resolution
+	series        *candles.Series
+	picker        *Dropdown
+	indicatorMenu *Dropdown
+	indicators    []*chartIndicator
+	loading       bool
+	loadErr       error
+	backfills     chan backfillResult
+	// generation identifies the latest backfill, older results are dropped
+	generation int
+	fetching   bool
+	// retries counts the failed backfills in a row, retryAt is when the
+	// next one starts
+	retries int
+	retryAt time.Time
+	// live is signalled when the connection goes live
+	live          chan struct{}
+	tradesVersion uint64
+	lastTradeSeq  int64
+	candleWidth   float32
+}
+
+func NewInstrumentGraph(deribitClient *client.DeribitClient, store *marketdata.Store, font rl.Font) *InstrumentGraph {
+	g := &InstrumentGraph{
+		client:        deribitClient,
+		store:         store,
+		resolution:    candles.Resolutions[0],
+		picker:        NewDropdown(0, 0, 64, candles.ResolutionNames(), "", font),
+		indicatorMenu: NewDropdown(0, 0, 120, indicatorMenuOptions(), "", font),
+		backfills:     make(chan backfillResult, 1),
+		live:          make(chan struct{}, 1),
+		candleWidth:   8,
+	}
+
+	deribitClient.OnStateChange(func(state client.ConnState) {
+		if state == client.StateLive {
+			select {
+			case g.live <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	g.picker.SetOnChangeHandler(func(index int) {
+		g.resolution = candles.Resolutions[index]
+		g.load(g.instrument)
+	})
+
+	// The menu always shows its title, picking an entry adds that indicator
+	g.indicatorMenu.SetOnChangeHandler(func(index int) {
+		if index > 0 {
+			g.AddIndicator(indicatorKinds[index-1].name)
+		}
+		g.indicatorMenu.SelectedIndex = 0
+	})
+	return g
+}
+
+// AddIndicator adds an indicator by name (SMA, EMA, WMA, VWAP, BB, RSI,
+// MACD, ATR or Stoch) with its default parameters
+func (g *InstrumentGraph) AddIndicator(name string) {
+	for i := range indicatorKinds {
+		if indicatorKinds[i].name == name {
+			g.indicators = append(g.indicators, newChartIndicator(&indicatorKinds[i]))
+			return
+		}
+	}
+}
+
+// RemoveIndicator removes the i-th indicator
+func (g *InstrumentGraph) RemoveIndicator(i int) {
+	if i >= 0 && i < len(g.indicators) {
+		g.indicators = append(g.indicators[:i], g.indicators[i+1:]...)
+	}
+}
+
+// load starts backfilling history for the instrument in the background
+func (g *InstrumentGraph) load(instrument string) {
+	g.instrument = instrument
+	g.series = candles.NewSeries(g.resolution.Duration, graphMaxBars)
+	g.loading = true
+	g.loadErr = nil
+	g.retries = 0
+	g.tradesVersion = 0
+	g.lastTradeSeq = 0
+	for _, indicator := range g.indicators {
+		indicator.reset()
+	}
+	g.fetch()
+}
+
+// fetch backfills history in the background, from the oldest bar shown so
+// a refetch keeps the history the chart has. Any backfill still running is
+// superseded.
+func (g *InstrumentGraph) fetch() {
+	g.generation++
+	g.fetching = true
+	g.retryAt = time.Time{}
+
+	generation := g.generation
+	instrument := g.instrument
+	resolution := g.resolution
+	end := time.Now()
+	start := end.Add(-graphBackfillBars * resolution.Duration)
+	if bars := g.series.Candles(); len(bars) > 0 {
+		start = bars[0].Time
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		history, err := g.client.FetchCandles(ctx, instrument, resolution.Source, start, end)
+		if err != nil {
+			log.Printf("Failed to backfill %s %s: %v", instrument, resolution.Name, err)
+		}
+		g.backfills <- backfillResult{generation: generation, end: end, candles: history, err: err}
+	}()
+}
+
+// refetch starts a backfill when the connection went live, to fill what
+// was missed while it was down, or when a failed one is due for a retry
+func (g *InstrumentGraph) refetch() {
+	select {
+	case <-g.live:
+		g.fetch()
+		return
+	default:
+	}
+	if !g.fetching && !g.retryAt.IsZero() && time.Now().After(g.retryAt) {
+		g.fetch()
+	}
+}
+
+// receiveBackfill takes a finished backfill if one is waiting. The history
+// replaces the candles, then trades printed after it ends are rolled in.
+func (g *InstrumentGraph) receiveBackfill() {
+	var result backfillResult
+	select {
+	case result = <-g.backfills:
+	default:
+		return
+	}
+	// A backfill superseded by a switch or a later fetch
+	if result.generation != g.generation {
+		return
+	}
+	g.fetching = false
+	g.loading = false
+	g.loadErr = result.err
+
+	if result.err != nil {
+		// Keep what the chart has and try again later
+		g.retryAt = time.Now().Add(min(graphRetryMin<<min(g.retries, 6), graphRetryMax))
+		g.retries++
+		return
+	}
+	g.retries = 0
+
+	g.series = candles.NewSeries(g.resolution.Duration, graphMaxBars)
+	for _, candle := range result.candles {
+		g.series.AddCandle(candle)
+	}
+	for _, indicator := range g.indicators {
+		indicator.reset()
+	}
+
+	// Trades up to the end are part of the history, newer ones are
+	// applied on top of it
+	trades, _ := g.store.Trades(g.instrument)
+	g.tradesVersion = 0
+	g.lastTradeSeq = 0
+	for _, trade := range trades {
+		if !trade.Timestamp.After(result.end) {
+			g.lastTradeSeq = trade.TradeSeq
+			break
+		}
+	}
+}
+
+// applyTrades rolls trades published since the last frame into the
+// candles. Trade amounts are converted to the base currency of the
+// backfilled volume, so trades wait for the instrument's specification.
+func (g *InstrumentGraph) applyTrades() {
+	spec := g.store.Instrument(g.instrument)
+	if spec == nil {
+		return
+	}
+	trades, version := g.store.Trades(g.instrument)
+	if version == g.tradesVersion {
+		return
+	}
+	g.tradesVersion = version
+
+	// Trades are newest first, find where the new ones end
+	newCount := 0
+	for newCount < len(trades) && trades[newCount].TradeSeq > g.lastTradeSeq {
+		newCount++
+	}
+	for i := newCount - 1; i >= 0; i-- {
+		trade := trades[i]
+		trade.Amount = spec.BaseAmount(trade.Amount, trade.Price)
+		g.series.AddTrade(trade)
+	}
+	if newCount > 0 {
+		g.lastTradeSeq = trades[0].TradeSeq
+	}
+}
+
+func (g *InstrumentGraph) Render(w *models.Window) {
+	if w.Instrument != g.instrument {
+		g.load(w.Instrument)
+	}
+	g.refetch()
+	g.receiveBackfill()
+
+	// Pickers sit at the right of the info line, drawn last so their
+	// options open over the chart
+	g.picker.Rect.X = w.Rect.X + w.Rect.Width - w.Padding - g.picker.Rect.Width
+	g.picker.Rect.Y = w.Rect.Y + 30
+	g.indicatorMenu.Rect.X = g.picker.Rect.X - 6 - g.indicatorMenu.Rect.Width
+	g.indicatorMenu.Rect.Y = g.picker.Rect.Y
+	g.picker.Update()
+	g.indicatorMenu.Update()
+	defer g.picker.Draw()
+	defer g.indicatorMenu.Draw()
+
+	if g.loading {
+		drawPlaceholder(w, fmt.Sprintf("Loading %s chart...", g.resolution.Name))
+		return
+	}
+	g.applyTrades()
+
+	if len(g.series.Candles()) == 0 {
+		if g.loadErr != nil {
+			drawPlaceholder(w, fmt.Sprintf("Chart unavailable, retrying: %v", g.loadErr))
+		} else {
+			drawPlaceholder(w, "Waiting for trades...")
+		}
+		return
+	}
+
+	resolution := g.resolution.Duration
+	var overlays, oscillators []*chartIndicator
+	for _, indicator := range g.indicators {
+		indicator.sync(g.series.Candles(), resolution)
+		if indicator.kind.overlay {
+			overlays = append(overlays, indicator)
+		} else {
+			oscillators = append(oscillators, indicator)
+		}
+	}
+
+	// Layout: info line, then the price pane, volume pane and one pane per
+	// oscillator share the plot width, price labels on the right, time
+	// labels at the bottom
+	contentX := w.Rect.X + w.Padding
+	contentY := w.Rect.Y + 30
+	contentWidth := w.Rect.Width - w.Padding*2
+	contentHeight := w.Rect.Height - 30 - w.Padding
+
+	plot := rl.Rectangle{
+		X:      contentX,
+		Y:      contentY + graphInfoHeight,
+		Width:  contentWidth - graphAxisWidth,
+		Height: contentHeight - graphInfoHeight - graphTimeAxisHeight,
+	}
+	oscillatorHeight := min(graphOscillatorPane, plot.Height*0.2)
+	mainHeight := plot.Height - float32(len(oscillators))*(oscillatorHeight+4)
+	if plot.Width < 50 || mainHeight < 50 {
+		return
+	}
+	pricePane := plot
+	pricePane.Height = mainHeight * 0.78
+	volumePane := plot
+	volumePane.Y = pricePane.Y + pricePane.Height + 4
+	volumePane.Height = mainHeight - pricePane.Height - 4
+
+	// Zoom with the mouse wheel over the plot
+	mousePos := rl.GetMousePosition()
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		if wheel := models.GetMouseWheelMove(); wheel != 0 {
+			g.candleWidth = float32(math.Max(3, math.Min(30, float64(g.candleWidth+wheel))))
+		}
+	}
+
+	bars := g.series.Candles()
+	visible := int(plot.Width / g.candleWidth)
+	bars = bars[max(0, len(bars)-visible):]
+
+	// Price and volume ranges over the visible bars and overlays
+	low, high, maxVolume := math.Inf(1), math.Inf(-1), 0.0
+	for _, candle := range bars {
+		low = math.Min(low, candle.Low)
+		high = math.Max(high, candle.High)
+		maxVolume = math.Max(maxVolume, candle.Volume)
+	}
+	for _, overlay := range overlays {
+		low, high = overlay.valueRange(bars, resolution, low, high)
+	}
+	padding := (high - low) * 0.05
+	if padding == 0 {
+		padding = high * 0.001
+	}
+	low -= padding
+	high += padding
+
+	priceY := func(price float64) float32 {
+		return pricePane.Y + float32((high-price)/(high-low))*pricePane.Height
+	}
+	candleX := func(i int) float32 {
+		// Latest bar on the right edge
+		return plot.X + plot.Width - float32(len(bars)-i)*g.candleWidth
+	}
+	barCenter := func(i int) float32 {
+		return candleX(i) + g.candleWidth/2
+	}
+
+	rl.DrawRectangleRec(rl.Rectangle{X: plot.X, Y: plot.Y, Width: plot.Width, Height: mainHeight}, colors.ColorChartBg)
+
+	// Price grid and axis labels
+	for i := 0; i <= 4; i++ {
+		price := low + (high-low)*float64(i)/4
+		y := priceY(price)
+		rl.DrawLine(int32(plot.X), int32(y), int32(plot.X+plot.Width), int32(y), colors.ColorBorder)
+		rl.DrawTextEx(w.Font, w.Spec.FormatPrice(price),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: y - 8}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Time labels roughly every 110 pixels, anchored to bar times so they
+	// don't jump around as new bars come in
+	labelEvery := int64(math.Ceil(float64(110 / g.candleWidth)))
+	timeFormat := "15:04"
+	switch {
+	case resolution >= 24*time.Hour:
+		timeFormat = "Jan 02"
+	case resolution >= time.Hour:
+		timeFormat = "02 15:04"
+	}
+	for i, candle := range bars {
+		if (candle.Time.Unix()/int64(resolution.Seconds()))%labelEvery != 0 {
+			continue
+		}
+		label := candle.Time.Local().Format(timeFormat)
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		x := barCenter(i)
+		rl.DrawLine(int32(x), int32(plot.Y), int32(x), int32(plot.Y+mainHeight), colors.ColorBorder)
+		rl.DrawTextEx(w.Font, label,
+			rl.Vector2{X: x - labelSize.X/2, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Candles and volume bars
+	bodyWidth := max(1, g.candleWidth-2)
+	for i, candle := range bars {
+		x := candleX(i)
+		color := colors.ColorGreen
+		if candle.Close < candle.Open {
+			color = colors.ColorRed
+		}
+
+		center := barCenter(i)
+		rl.DrawLineEx(
+			rl.Vector2{X: center, Y: priceY(candle.High)},
+			rl.Vector2{X: center, Y: priceY(candle.Low)},
+			1, color)
+
+		bodyTop := priceY(math.Max(candle.Open, candle.Close))
+		bodyHeight := float32(math.Max(1, float64(priceY(math.Min(candle.Open, candle.Close))-bodyTop)))
+		rl.DrawRectangleRec(rl.Rectangle{X: x + 1, Y: bodyTop, Width: bodyWidth, Height: bodyHeight}, color)
+
+		if maxVolume > 0 {
+			volumeHeight := float32(candle.Volume/maxVolume) * volumePane.Height
+			rl.DrawRectangleRec(rl.Rectangle{
+				X:      x + 1,
+				Y:      volumePane.Y + volumePane.Height - volumeHeight,
+				Width:  bodyWidth,
+				Height: volumeHeight,
+			}, rl.NewColor(color.R, color.G, color.B, 90))
+		}
+	}
+
+	// Overlays on the price pane, the price range already covers them
+	for _, overlay := range overlays {
+		overlay.drawLines(bars, resolution, barCenter, priceY)
+	}
+
+	// Last price marker on the axis
+	last := bars[len(bars)-1]
+	lastY := priceY(last.Close)
+	rl.DrawLine(int32(plot.X), int32(lastY), int32(plot.X+plot.Width), int32(lastY), rl.NewColor(210, 210, 210, 60))
+	rl.DrawRectangleRec(rl.Rectangle{X: plot.X + plot.Width + 2, Y: lastY - 9, Width: graphAxisWidth - 4, Height: 18}, colors.ColorHeaderBg)
+	rl.DrawTextEx(w.Font, w.Spec.FormatPrice(last.Close),
+		rl.Vector2{X: plot.X + plot.Width + 6, Y: lastY - 8}, 14, 1, colors.ColorText)
+
+	// Info line and legends show the hovered bar, or the latest one
+	info := last
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		i := len(bars) - 1 - int((plot.X+plot.Width-mousePos.X)/g.candleWidth)
+		if i >= 0 && i < len(bars) {
+			info = bars[i]
+		}
+	}
+
+	// Oscillator panes under the volume pane, each with its own legend
+	removed := -1
+	paneY := plot.Y + mainHeight + 4
+	for _, oscillator := range oscillators {
+		pane := rl.Rectangle{X: plot.X, Y: paneY, Width: plot.Width, Height: oscillatorHeight}
+		oscillator.drawPane(w, pane, bars, resolution, barCenter, bodyWidth)
+		if _, action := oscillator.drawLegend(w, pane.X+4, pane.Y+2, oscillator.at(info.Time, resolution)); action == legendRemove {
+			removed = g.indexOf(oscillator)
+		}
+		paneY += oscillatorHeight + 4
+	}
+
+	// Overlay legends along the top of the price pane
+	legendX := pricePane.X + 4
+	for _, overlay := range overlays {
+		var action legendAction
+		legendX, action = overlay.drawLegend(w, legendX, pricePane.Y+2, overlay.at(info.Time, resolution))
+		if action == legendRemove {
+			removed = g.indexOf(overlay)
+		}
+	}
+	if removed >= 0 {
+		g.RemoveIndicator(removed)
+	}
+
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		rl.DrawLine(int32(mousePos.X), int32(plot.Y), int32(mousePos.X), int32(plot.Y+plot.Height), colors.ColorSubtext)
+		rl.DrawLine(int32(plot.X), int32(mousePos.Y), int32(plot.X+plot.Width), int32(mousePos.Y), colors.ColorSubtext)
+	}
+	rl.DrawTextEx(w.Font,
+		fmt.Sprintf("%s  %s  O %s  H %s  L %s  C %s  V %s",
+			g.resolution.Name, info.Time.Local().Format("01-02 15:04"),
+			w.Spec.FormatPrice(info.Open), w.Spec.FormatPrice(info.High), w.Spec.FormatPrice(info.Low), w.Spec.FormatPrice(info.Close),
+			formatIndicatorValue(info.Volume)),
+		rl.Vector2{X: contentX, Y: contentY + 2}, 14, 1, colors.ColorText)
+}
+
+func (g *InstrumentGraph) indexOf(indicator *chartIndicator) int {
+	for i, candidate := range g.indicators {
+		if candidate == indicator {
+			return i
+		}
+	}
+	return -1
+}
+
+// drawPlaceholder shows a status line in place of a window's content
+func drawPlaceholder(w *models.Window, text string) {
+	rl.DrawTextEx(
+		w.Font,
+		text,
+		rl.Vector2{X: w.Rect.X + w.Padding, Y: w.Rect.Y + 35},
+		16,
+		1,
+		colors.ColorSubtext)
+}
@@ -0,0 +1,328 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/indicators"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// indicatorKind describes an indicator that can be added to the chart
+type indicatorKind struct {
+	name string
+	// overlay indicators share the price pane, the others get their own pane
+	overlay bool
+	// params are the defaults, the first one can be changed from the legend
+	params []int
+	color  rl.Color
+	build  func(params []int) indicators.Indicator
+	// bounded oscillators are drawn on a fixed scale with guide lines,
+	// the rest scale to their visible values
+	bounded    bool
+	lowerGuide float64
+	upperGuide float64
+}
+
+var indicatorKinds = []indicatorKind{
+	{name: "SMA", overlay: true, params: []int{20}, color: colors.ColorBlue,
+		build: func(p []int) indicators.Indicator { return indicators.NewSMA(p[0]) }},
+	{name: "EMA", overlay: true, params: []int{50}, color: colors.ColorOrange,
+		build: func(p []int) indicators.Indicator { return indicators.NewEMA(p[0]) }},
+	{name: "WMA", overlay: true, params: []int{20}, color: colors.ColorPurple,
+		build: func(p []int) indicators.Indicator { return indicators.NewWMA(p[0]) }},
+	{name: "VWAP", overlay: true, color: colors.ColorYellow,
+		build: func(p []int) indicators.Indicator { return indicators.NewVWAP() }},
+	{name: "BB", overlay: true, params: []int{20}, color: colors.ColorCyan,
+		build: func(p []int) indicators.Indicator { return indicators.NewBollinger(p[0], 2) }},
+	{name: "RSI", params: []int{14}, color: colors.ColorPurple, bounded: true, lowerGuide: 30, upperGuide: 70,
+		build: func(p []int) indicators.Indicator { return indicators.NewRSI(p[0]) }},
+	{name: "MACD", params: []int{12, 26, 9}, color: colors.ColorBlue,
+		build: func(p []int) indicators.Indicator { return indicators.NewMACD(p[0], p[1], p[2]) }},
+	{name: "ATR", params: []int{14}, color: colors.ColorOrange,
+		build: func(p []int) indicators.Indicator { return indicators.NewATR(p[0]) }},
+	{name: "Stoch", params: []int{14, 3}, color: colors.ColorCyan, bounded: true, lowerGuide: 20, upperGuide: 80,
+		build: func(p []int) indicators.Indicator { return indicators.NewStochastic(p[0], p[1]) }},
+}
+
+// indicatorMenuOptions lists the add-indicator menu, the first entry is its title
+func indicatorMenuOptions() []string {
+	options := []string{"+ Indicator"}
+	for _, kind := range indicatorKinds {
+		options = append(options, kind.name)
+	}
+	return options
+}
+
+// chartIndicator is an indicator on the chart and its values per bar
+type chartIndicator struct {
+	kind      *indicatorKind
+	params    []int
+	color     rl.Color
+	indicator indicators.Indicator
+
+	// values[i] belongs to the bar at firstTime + i bars, the last one is
+	// the forming bar and is recomputed on every sync
+	values    [][]float64
+	firstTime time.Time
+	closed    int
+}
+
+func newChartIndicator(kind *indicatorKind) *chartIndicator {
+	params := append([]int{}, kind.params...)
+	return &chartIndicator{
+		kind:      kind,
+		params:    params,
+		color:     kind.color,
+		indicator: kind.build(params),
+	}
+}
+
+func (ci *chartIndicator) label() string {
+	if len(ci.params) == 0 {
+		return ci.kind.name
+	}
+	params := make([]string, len(ci.params))
+	for i, param := range ci.params {
+		params[i] = fmt.Sprint(param)
+	}
+	return fmt.Sprintf("%s(%s)", ci.kind.name, strings.Join(params, ","))
+}
+
+// setPeriod rebuilds the indicator with a new first parameter
+func (ci *chartIndicator) setPeriod(period int) {
+	if len(ci.params) == 0 || period < 2 {
+		return
+	}
+	ci.params[0] = period
+	ci.indicator = ci.kind.build(ci.params)
+	ci.values = nil
+	ci.closed = 0
+}
+
+// nextColor cycles the indicator through the palette
+func (ci *chartIndicator) nextColor() {
+	for i, color := range colors.IndicatorPalette {
+		if color == ci.color {
+			ci.color = colors.IndicatorPalette[(i+1)%len(colors.IndicatorPalette)]
+			return
+		}
+	}
+	ci.color = colors.IndicatorPalette[0]
+}
+
+func (ci *chartIndicator) reset() {
+	ci.indicator.Reset()
+	ci.values = nil
+	ci.closed = 0
+}
+
+// sync feeds bars the indicator has not seen yet. Every bar but the last
+// is closed; the last one is still forming and only previewed.
+func (ci *chartIndicator) sync(bars []models.Candle, resolution time.Duration) {
+	if len(bars) == 0 {
+		return
+	}
+
+	if len(ci.values) == 0 {
+		ci.firstTime = bars[0].Time
+	} else if bars[0].Time.After(ci.firstTime) {
+		// The series dropped its oldest bars, drop their values too
+		drop := min(int(bars[0].Time.Sub(ci.firstTime)/resolution), len(ci.values))
+		ci.values = ci.values[drop:]
+		ci.closed = max(0, ci.closed-drop)
+		ci.firstTime = bars[0].Time
+	}
+
+	for len(ci.values) < len(bars) {
+		ci.values = append(ci.values, nil)
+	}
+	for ; ci.closed < len(bars)-1; ci.closed++ {
+		ci.values[ci.closed] = ci.indicator.Update(bars[ci.closed], true)
+	}
+	ci.values[len(bars)-1] = ci.indicator.Update(bars[len(bars)-1], false)
+}
+
+// at returns the values for the bar starting at t, nil if there are none
+func (ci *chartIndicator) at(t time.Time, resolution time.Duration) []float64 {
+	i := int(t.Sub(ci.firstTime) / resolution)
+	if t.Before(ci.firstTime) || i >= len(ci.values) {
+		return nil
+	}
+	return ci.values[i]
+}
+
+// lineColor is the indicator color for the main line, dimmed for the others
+func (ci *chartIndicator) lineColor(line int) rl.Color {
+	if line == 0 {
+		return ci.color
+	}
+	return rl.NewColor(ci.color.R, ci.color.G, ci.color.B, 140)
+}
+
+// drawLines plots every line of the indicator over the visible bars
+func (ci *chartIndicator) drawLines(bars []models.Candle, resolution time.Duration, barCenter func(int) float32, valueY func(float64) float32) {
+	if len(bars) == 0 {
+		return
+	}
+	lines := len(ci.at(bars[len(bars)-1].Time, resolution))
+
+	for line := 0; line < lines; line++ {
+		// The MACD histogram is drawn as bars, not a line
+		if ci.kind.name == "MACD" && line == 2 {
+			continue
+		}
+
+		var previous rl.Vector2
+		hasPrevious := false
+		for i, bar := range bars {
+			values := ci.at(bar.Time, resolution)
+			if line >= len(values) || math.IsNaN(values[line]) {
+				hasPrevious = false
+				continue
+			}
+			point := rl.Vector2{X: barCenter(i), Y: valueY(values[line])}
+			if hasPrevious {
+				rl.DrawLineEx(previous, point, 1.5, ci.lineColor(line))
+			}
+			previous, hasPrevious = point, true
+		}
+	}
+}
+
+// valueRange widens low and high to cover the indicator's visible values
+func (ci *chartIndicator) valueRange(bars []models.Candle, resolution time.Duration, low, high float64) (float64, float64) {
+	for _, bar := range bars {
+		for _, value := range ci.at(bar.Time, resolution) {
+			if !math.IsNaN(value) {
+				low = math.Min(low, value)
+				high = math.Max(high, value)
+			}
+		}
+	}
+	return low, high
+}
+
+// drawPane draws an oscillator in its own pane below the price chart
+func (ci *chartIndicator) drawPane(w *models.Window, pane rl.Rectangle, bars []models.Candle, resolution time.Duration, barCenter func(int) float32, bodyWidth float32) {
+	rl.DrawRectangleRec(pane, colors.ColorChartBg)
+
+	low, high := 0.0, 100.0
+	if !ci.kind.bounded {
+		low, high = ci.valueRange(bars, resolution, math.Inf(1), math.Inf(-1))
+		if math.IsInf(low, 0) {
+			return
+		}
+		if high == low {
+			high, low = high+1, low-1
+		}
+	}
+	valueY := func(value float64) float32 {
+		return pane.Y + float32((high-value)/(high-low))*pane.Height
+	}
+
+	if ci.kind.bounded {
+		for _, guide := range []float64{ci.kind.lowerGuide, ci.kind.upperGuide} {
+			y := valueY(guide)
+			rl.DrawLine(int32(pane.X), int32(y), int32(pane.X+pane.Width), int32(y), colors.ColorBorder)
+		}
+	}
+
+	if ci.kind.name == "MACD" {
+		// Histogram around the zero line
+		zeroY := valueY(0)
+		rl.DrawLine(int32(pane.X), int32(zeroY), int32(pane.X+pane.Width), int32(zeroY), colors.ColorBorder)
+		for i, bar := range bars {
+			values := ci.at(bar.Time, resolution)
+			if len(values) < 3 || math.IsNaN(values[2]) {
+				continue
+			}
+			color := rl.NewColor(colors.ColorGreen.R, colors.ColorGreen.G, colors.ColorGreen.B, 110)
+			if values[2] < 0 {
+				color = rl.NewColor(colors.ColorRed.R, colors.ColorRed.G, colors.ColorRed.B, 110)
+			}
+			y := valueY(values[2])
+			top, bottom := min(y, zeroY), max(y, zeroY)
+			rl.DrawRectangleRec(rl.Rectangle{X: barCenter(i) - bodyWidth/2, Y: top, Width: bodyWidth, Height: max(1, bottom-top)}, color)
+		}
+	}
+
+	ci.drawLines(bars, resolution, barCenter, valueY)
+
+	// Scale labels on the axis
+	rl.DrawTextEx(w.Font, formatIndicatorValue(high),
+		rl.Vector2{X: pane.X + pane.Width + 6, Y: pane.Y}, 12, 1, colors.ColorSubtext)
+	rl.DrawTextEx(w.Font, formatIndicatorValue(low),
+		rl.Vector2{X: pane.X + pane.Width + 6, Y: pane.Y + pane.Height - 12}, 12, 1, colors.ColorSubtext)
+}
+
+func formatIndicatorValue(value float64) string {
+	if math.Abs(value) >= 1000 {
+		return fmt.Sprintf("%.1f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// legendAction is what a click on an indicator's legend entry asked for
+type legendAction int
+
+const (
+	legendNone legendAction = iota
+	legendRemove
+)
+
+// drawLegend draws the indicator's label with its value at the hovered bar
+// and the period and remove buttons, starting at x. Clicking the label
+// cycles the color. It returns the x after the entry and any removal.
+func (ci *chartIndicator) drawLegend(w *models.Window, x, y float32, values []float64) (float32, legendAction) {
+	const fontSize = 14
+	mousePos := rl.GetMousePosition()
+	clicked := func(rect rl.Rectangle) bool {
+		return models.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mousePos, rect)
+	}
+
+	text := ci.label()
+	for i, value := range values {
+		if ci.kind.name == "MACD" && i == 2 {
+			break
+		}
+		if !math.IsNaN(value) {
+			text += " " + formatIndicatorValue(value)
+		}
+	}
+
+	size := rl.MeasureTextEx(w.Font, text, fontSize, 1)
+	labelRect := rl.Rectangle{X: x, Y: y, Width: size.X, Height: size.Y}
+	rl.DrawTextEx(w.Font, text, rl.Vector2{X: x, Y: y}, fontSize, 1, ci.color)
+	if clicked(labelRect) {
+		ci.nextColor()
+	}
+	x += size.X + 6
+
+	action := legendNone
+	buttons := []string{"-", "+", "x"}
+	if len(ci.params) == 0 {
+		buttons = []string{"x"}
+	}
+	for _, button := range buttons {
+		rect := rl.Rectangle{X: x, Y: y, Width: 12, Height: size.Y}
+		rl.DrawTextEx(w.Font, button, rl.Vector2{X: x + 2, Y: y}, fontSize, 1, colors.ColorSubtext)
+		if clicked(rect) {
+			switch button {
+			case "-":
+				ci.setPeriod(ci.params[0] - 1)
+			case "+":
+				ci.setPeriod(ci.params[0] + 1)
+			case "x":
+				action = legendRemove
+			}
+		}
+		x += 14
+	}
+
+	return x + 10, action
+}
@@ -0,0 +1,390 @@
+package components
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/heatmap"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	heatmapInterval = time.Second
+	// Four hours of one second samples, about 17MB of sizes
+	heatmapCapacity = 4 * 60 * 60
+	heatmapDepth    = 300
+)
+
+// heatmapPalette maps intensity 0-255 to a color, dark for thin levels
+// through blue and cyan to yellow and white for the largest
+var heatmapPalette = func() [256]color.RGBA {
+	stops := []color.RGBA{
+		colors.ColorChartBg,
+		rl.NewColor(20, 50, 120, 255),
+		colors.ColorCyan,
+		colors.ColorYellow,
+		rl.NewColor(255, 255, 255, 255),
+	}
+	var palette [256]color.RGBA
+	for i := range palette {
+		position := float64(i) / 255 * float64(len(stops)-1)
+		from := int(position)
+		to := min(from+1, len(stops)-1)
+		t := position - float64(from)
+		mix := func(a, b uint8) uint8 {
+			return uint8(float64(a) + (float64(b)-float64(a))*t)
+		}
+		palette[i] = rl.NewColor(
+			mix(stops[from].R, stops[to].R),
+			mix(stops[from].G, stops[to].G),
+			mix(stops[from].B, stops[to].B),
+			255)
+	}
+	return palette
+}()
+
+// heatmapSample is the latest book and the trades since the previous
+// sample, taken on the sampler goroutine for the render thread
+type heatmapSample struct {
+	instrument string
+	time       time.Time
+	book       *models.OrderBookResult
+	// trades are oldest first
+	trades []models.Trade
+}
+
+// LiquidityHeatmap samples the order book once a second and shows resting
+// size per price over time, with the traded price and trades on top.
+// Samples are taken on a goroutine of their own from the latest book in
+// the store, so they keep an even pace whatever the frame rate, whether
+// or not the window is drawn and however quiet the book is. The render
+// thread owns the history and the cells are drawn into a texture that is
+// only rebuilt when a sample comes in or the view changes.
+type LiquidityHeatmap struct {
+	store *marketdata.Store
+
+	// following is the instrument the sampler takes samples of
+	following atomic.Value
+	samples   chan heatmapSample
+	done      chan struct{}
+	closeOnce sync.Once
+
+	instrument string
+	history    *heatmap.History
+	dirty      bool
+
+	// columnWidth and rowHeight are the pixels per sample and per bucket
+	columnWidth float32
+	rowHeight   float32
+
+	texture     rl.Texture2D
+	pixels      []color.RGBA
+	textureCols int
+	textureRows int
+	textureTop  int64
+}
+
+func NewLiquidityHeatmap(store *marketdata.Store) *LiquidityHeatmap {
+	h := &LiquidityHeatmap{
+		store:       store,
+		samples:     make(chan heatmapSample, 16),
+		done:        make(chan struct{}),
+		history:     heatmap.NewHistory(heatmapInterval, heatmapCapacity, heatmapDepth),
+		columnWidth: 2,
+		rowHeight:   3,
+	}
+	h.following.Store("")
+	go h.sample()
+	return h
+}
+
+// sample takes a sample of the followed instrument every interval until
+// Close. Trades published before the instrument was followed belong to
+// nobody's column.
+func (h *LiquidityHeatmap) sample() {
+	ticker := time.NewTicker(heatmapInterval)
+	defer ticker.Stop()
+
+	var instrument string
+	var lastTradeSeq int64
+	for {
+		var now time.Time
+		select {
+		case <-h.done:
+			return
+		case now = <-ticker.C:
+		}
+
+		following := h.following.Load().(string)
+		trades, _ := h.store.Trades(following)
+		if following != instrument {
+			instrument = following
+			lastTradeSeq = 0
+			if len(trades) > 0 {
+				lastTradeSeq = trades[0].TradeSeq
+			}
+		}
+		if instrument == "" {
+			continue
+		}
+
+		// Trades are newest first, hand the new ones over oldest first
+		sample := heatmapSample{instrument: instrument, time: now}
+		sample.book, _ = h.store.OrderBook(instrument)
+		for i := len(trades) - 1; i >= 0; i-- {
+			if trades[i].TradeSeq > lastTradeSeq {
+				sample.trades = append(sample.trades, trades[i])
+				lastTradeSeq = trades[i].TradeSeq
+			}
+		}
+
+		select {
+		case <-h.done:
+			return
+		case h.samples <- sample:
+		}
+	}
+}
+
+// receiveSamples adds the samples taken since the last frame to the history
+func (h *LiquidityHeatmap) receiveSamples() {
+	for {
+		var sample heatmapSample
+		select {
+		case sample = <-h.samples:
+		default:
+			return
+		}
+		// Taken before a switch
+		if sample.instrument != h.instrument {
+			continue
+		}
+
+		for _, trade := range sample.trades {
+			h.history.AddTrade(trade)
+		}
+		if sample.book != nil && !sample.book.Resyncing && h.history.Due(sample.time) {
+			h.history.Sample(sample.time, sample.book)
+			h.dirty = true
+		}
+	}
+}
+
+// follow starts the history over for another instrument
+func (h *LiquidityHeatmap) follow(instrument string) {
+	h.instrument = instrument
+	h.following.Store(instrument)
+	h.history.Reset()
+	h.dirty = true
+}
+
+// Close stops sampling and frees the texture, call it before the raylib
+// window is closed
+func (h *LiquidityHeatmap) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+	if h.texture.ID != 0 {
+		rl.UnloadTexture(h.texture)
+		h.texture = rl.Texture2D{}
+	}
+}
+
+func (h *LiquidityHeatmap) Render(w *models.Window) {
+	if w.Instrument != h.instrument {
+		h.follow(w.Instrument)
+	}
+	h.receiveSamples()
+
+	if h.history.Len() == 0 {
+		drawPlaceholder(w, "Collecting order book samples...")
+		return
+	}
+
+	contentX := w.Rect.X + w.Padding
+	contentY := w.Rect.Y + 30
+	contentWidth := w.Rect.Width - w.Padding*2
+	contentHeight := w.Rect.Height - 30 - w.Padding
+
+	plot := rl.Rectangle{
+		X:      contentX,
+		Y:      contentY + 20,
+		Width:  contentWidth - graphAxisWidth,
+		Height: contentHeight - 20 - graphTimeAxisHeight,
+	}
+	if plot.Width < 50 || plot.Height < 30 {
+		return
+	}
+
+	// Wheel zooms the price axis, with shift held the time axis
+	mousePos := rl.GetMousePosition()
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		if wheel := models.GetMouseWheelMove(); wheel != 0 {
+			if models.IsKeyDown(rl.KeyLeftShift) || models.IsKeyDown(rl.KeyRightShift) {
+				h.columnWidth = float32(math.Max(1, math.Min(12, float64(h.columnWidth+wheel))))
+			} else {
+				h.rowHeight = float32(math.Max(1, math.Min(16, float64(h.rowHeight+wheel))))
+			}
+		}
+	}
+
+	// The view follows the latest mid, newest sample on the right
+	cols := int(plot.Width / h.columnWidth)
+	rows := int(plot.Height / h.rowHeight)
+	latest := h.history.At(h.history.Len() - 1)
+	top := latest.Mid + int64(rows/2)
+	first := h.history.Len() - cols
+
+	if h.dirty || cols != h.textureCols || rows != h.textureRows || top != h.textureTop {
+		h.updateTexture(cols, rows, top, first)
+	}
+
+	rowY := func(bucket int64) float32 {
+		return plot.Y + float32(top-bucket)*h.rowHeight
+	}
+	priceY := func(price float64) float32 {
+		bucket := price / h.history.BucketSize()
+		return plot.Y + float32(float64(top+1)-bucket)*h.rowHeight
+	}
+	columnX := func(i int) float32 {
+		return plot.X + float32(i-first)*h.columnWidth + h.columnWidth/2
+	}
+
+	rl.DrawRectangleRec(plot, colors.ColorChartBg)
+	rl.DrawTexturePro(h.texture,
+		rl.Rectangle{X: 0, Y: 0, Width: float32(cols), Height: float32(rows)},
+		rl.Rectangle{X: plot.X, Y: plot.Y, Width: float32(cols) * h.columnWidth, Height: float32(rows) * h.rowHeight},
+		rl.Vector2{}, 0, rl.White)
+
+	// Traded price line and trade bubbles sized by volume
+	rl.BeginScissorMode(int32(plot.X), int32(plot.Y), int32(plot.Width), int32(plot.Height))
+	maxVolume := 0.0
+	for i := max(0, first); i < h.history.Len(); i++ {
+		maxVolume = math.Max(maxVolume, h.history.At(i).Volume())
+	}
+	var previous rl.Vector2
+	hasPrevious := false
+	for i := max(0, first); i < h.history.Len(); i++ {
+		column := h.history.At(i)
+		if column.LastPrice == 0 {
+			continue
+		}
+		point := rl.Vector2{X: columnX(i), Y: priceY(column.LastPrice)}
+		if hasPrevious {
+			rl.DrawLineEx(previous, point, 1.5, colors.ColorText)
+		}
+		previous, hasPrevious = point, true
+	}
+	for i := max(0, first); i < h.history.Len(); i++ {
+		column := h.history.At(i)
+		if column.Volume() == 0 {
+			continue
+		}
+		bubble := colors.ColorGreen
+		if column.SellVolume > column.BuyVolume {
+			bubble = colors.ColorRed
+		}
+		radius := float32(2 + 10*math.Sqrt(column.Volume()/maxVolume))
+		center := rl.Vector2{X: columnX(i), Y: priceY(column.TradePrice)}
+		rl.DrawCircleV(center, radius, rl.NewColor(bubble.R, bubble.G, bubble.B, 140))
+		rl.DrawCircleLines(int32(center.X), int32(center.Y), radius, bubble)
+	}
+	rl.EndScissorMode()
+	w.ClipContent()
+
+	// Price axis labels about every 40 pixels
+	labelEvery := int64(math.Ceil(float64(40 / h.rowHeight)))
+	for bucket := top; bucket > top-int64(rows); bucket-- {
+		if bucket%labelEvery != 0 {
+			continue
+		}
+		y := rowY(bucket) + h.rowHeight/2
+		rl.DrawTextEx(w.Font, w.Spec.FormatPrice(h.history.Price(bucket)),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: y - 7}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Time labels about every 110 pixels, anchored to the sample clock
+	timeEvery := int64(math.Ceil(float64(110 / h.columnWidth)))
+	for i := max(0, first); i < h.history.Len(); i++ {
+		column := h.history.At(i)
+		if column.Time.Unix()%timeEvery != 0 {
+			continue
+		}
+		label := column.Time.Local().Format("15:04:05")
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		rl.DrawTextEx(w.Font, label,
+			rl.Vector2{X: columnX(i) - labelSize.X/2, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Header line, or the hovered cell
+	info := fmt.Sprintf("Bucket %s  %d samples", w.Spec.FormatPrice(h.history.BucketSize()), h.history.Len())
+	if rl.CheckCollisionPointRec(mousePos, plot) {
+		i := first + int((mousePos.X-plot.X)/h.columnWidth)
+		bucket := top - int64((mousePos.Y-plot.Y)/h.rowHeight)
+		if i >= 0 && i < h.history.Len() {
+			column := h.history.At(i)
+			info = fmt.Sprintf("%s  %s  size %s",
+				column.Time.Local().Format("15:04:05"), w.Spec.FormatPrice(h.history.Price(bucket)), w.Spec.FormatAmount(column.Size(bucket)))
+			if column.Volume() > 0 {
+				info += fmt.Sprintf("  traded %s @ %s", w.Spec.FormatAmount(column.Volume()), w.Spec.FormatPrice(column.TradePrice))
+			}
+		}
+		rl.DrawLine(int32(plot.X), int32(mousePos.Y), int32(plot.X+plot.Width), int32(mousePos.Y), colors.ColorSubtext)
+	}
+	rl.DrawTextEx(w.Font, info, rl.Vector2{X: contentX, Y: contentY + 2}, 14, 1, colors.ColorText)
+}
+
+// updateTexture redraws the cells into the texture, one pixel per sample
+// and bucket, scaled up when drawn
+func (h *LiquidityHeatmap) updateTexture(cols, rows int, top int64, first int) {
+	if cols != h.textureCols || rows != h.textureRows || h.texture.ID == 0 {
+		if h.texture.ID != 0 {
+			rl.UnloadTexture(h.texture)
+		}
+		image := rl.GenImageColor(cols, rows, colors.ColorChartBg)
+		h.texture = rl.LoadTextureFromImage(image)
+		rl.UnloadImage(image)
+		rl.SetTextureFilter(h.texture, rl.FilterPoint)
+		h.pixels = make([]color.RGBA, cols*rows)
+	}
+
+	// Intensity is relative to the largest visible level, square root
+	// scaled so thinner levels still show
+	maxSize := 0.0
+	for x := 0; x < cols; x++ {
+		if first+x < 0 {
+			continue
+		}
+		column := h.history.At(first + x)
+		for y := 0; y < rows; y++ {
+			maxSize = math.Max(maxSize, column.Size(top-int64(y)))
+		}
+	}
+
+	for x := 0; x < cols; x++ {
+		var column *heatmap.Column
+		if first+x >= 0 {
+			column = h.history.At(first + x)
+		}
+		for y := 0; y < rows; y++ {
+			intensity := 0
+			if column != nil && maxSize > 0 {
+				intensity = int(math.Sqrt(column.Size(top-int64(y))/maxSize) * 255)
+			}
+			h.pixels[y*cols+x] = heatmapPalette[intensity]
+		}
+	}
+	rl.UpdateTexture(h.texture, h.pixels)
+
+	h.dirty = false
+	h.textureCols = cols
+	h.textureRows = rows
+	h.textureTop = top
+}
@@ -0,0 +1,452 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/registry"
+	"github.com/adityanagar10/trader/settings"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	pickerRowHeight   = float32(22)
+	pickerVisibleRows = 18
+	pickerRecentSize  = 8
+	// pickerMaxResults bounds a filtered list, nobody scrolls past that
+	pickerMaxResults = 300
+	recentFile       = "recent_instruments.json"
+)
+
+// pickerRow is a group header or an instrument in the result list
+type pickerRow struct {
+	header     string
+	instrument string
+}
+
+// InstrumentPicker is a searchable instrument selector. Typing filters
+// the registry with fuzzy matching, arrows and Enter pick a result, and
+// recently used instruments are listed first.
+type InstrumentPicker struct {
+	Rect     rl.Rectangle
+	Font     rl.Font
+	IsOpen   bool
+	Selected string
+
+	registry *registry.Registry
+	// fallback is offered while the registry is empty
+	fallback []string
+	version  uint64
+	recent   []string
+
+	query   string
+	rows    []pickerRow
+	matched int
+	cursor  int
+	scroll  int
+
+	onSelect func(instrument string)
+}
+
+func NewInstrumentPicker(x, y, width float32, instruments *registry.Registry, selected string, fallback []string, font rl.Font) *InstrumentPicker {
+	p := &InstrumentPicker{
+		Rect:     rl.NewRectangle(x, y, width, 26),
+		Font:     font,
+		Selected: selected,
+		registry: instruments,
+		fallback: fallback,
+	}
+	if err := settings.Load(recentFile, &p.recent); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to load recent instruments: %v", err)
+	}
+	return p
+}
+
+// SetOnSelectHandler sets the callback for when an instrument is picked
+func (p *InstrumentPicker) SetOnSelectHandler(handler func(string)) {
+	p.onSelect = handler
+}
+
+func (p *InstrumentPicker) open() {
+	p.IsOpen = true
+	p.query = ""
+	p.rebuild()
+	// Escape closes the picker instead of the app while it is open
+	rl.SetExitKey(rl.KeyNull)
+}
+
+func (p *InstrumentPicker) close() {
+	p.IsOpen = false
+	rl.SetExitKey(rl.KeyEscape)
+}
+
+// Select picks an instrument as if it was chosen from the list
+func (p *InstrumentPicker) Select(instrument string) {
+	p.close()
+
+	// Most recent first, without duplicates
+	recent := []string{instrument}
+	for _, name := range p.recent {
+		if name != instrument && len(recent) < pickerRecentSize {
+			recent = append(recent, name)
+		}
+	}
+	p.recent = recent
+	if err := settings.Save(recentFile, p.recent); err != nil {
+		log.Printf("Failed to save recent instruments: %v", err)
+	}
+
+	if instrument != p.Selected {
+		p.Selected = instrument
+		if p.onSelect != nil {
+			p.onSelect(instrument)
+		}
+	}
+}
+
+// candidates is everything that can be picked
+func (p *InstrumentPicker) candidates() []*models.Instrument {
+	if all := p.registry.All(); len(all) > 0 {
+		return all
+	}
+	instruments := make([]*models.Instrument, len(p.fallback))
+	for i, name := range p.fallback {
+		instruments[i] = &models.Instrument{InstrumentName: name}
+	}
+	return instruments
+}
+
+// rebuild filters the candidates by the query and groups the results
+func (p *InstrumentPicker) rebuild() {
+	p.version = p.registry.Version()
+	p.rows = p.rows[:0]
+	p.scroll = 0
+
+	type match struct {
+		instrument *models.Instrument
+		score      int
+	}
+	var matches []match
+	for _, instrument := range p.candidates() {
+		if score, ok := fuzzyScore(p.query, instrument.InstrumentName); ok {
+			matches = append(matches, match{instrument, score})
+		}
+	}
+
+	if p.query == "" {
+		// Recently used on top, then the registry in its own order
+		var recent []pickerRow
+		for _, name := range p.recent {
+			recent = append(recent, pickerRow{instrument: name})
+		}
+		if len(recent) > 0 {
+			p.rows = append(p.rows, pickerRow{header: "Recent"})
+			p.rows = append(p.rows, recent...)
+		}
+	} else {
+		// Best matches first; stable keeps registry order among equals
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+		matches = matches[:min(len(matches), pickerMaxResults)]
+	}
+	p.matched = len(matches)
+
+	// Groups are listed in the order of their first (best) match
+	var groups []string
+	grouped := make(map[string][]string)
+	for _, m := range matches {
+		group := pickerGroup(m.instrument)
+		if _, ok := grouped[group]; !ok {
+			groups = append(groups, group)
+		}
+		grouped[group] = append(grouped[group], m.instrument.InstrumentName)
+	}
+	for _, group := range groups {
+		p.rows = append(p.rows, pickerRow{header: group})
+		for _, name := range grouped[group] {
+			p.rows = append(p.rows, pickerRow{instrument: name})
+		}
+	}
+
+	p.cursor = -1
+	p.moveCursor(1)
+}
+
+// pickerGroup is the header an instrument is listed under
+func pickerGroup(instrument *models.Instrument) string {
+	currency := instrument.BaseCurrency
+	if currency == "" {
+		return "Instruments"
+	}
+	switch {
+	case instrument.Perpetual():
+		return currency + " perpetuals"
+	case instrument.Kind == "future":
+		return currency + " futures"
+	case instrument.Kind == "spot":
+		return currency + " spot"
+	case instrument.Kind == "option":
+		return currency + " options " + strings.ToUpper(instrument.Expiry().UTC().Format("02Jan06"))
+	}
+	return currency + " combos"
+}
+
+// moveCursor moves the cursor by delta instrument rows, skipping headers,
+// and scrolls it into view
+func (p *InstrumentPicker) moveCursor(delta int) {
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+	for ; delta > 0; delta-- {
+		next := p.cursor + step
+		for next >= 0 && next < len(p.rows) && p.rows[next].header != "" {
+			next += step
+		}
+		if next < 0 || next >= len(p.rows) {
+			break
+		}
+		p.cursor = next
+	}
+
+	if p.cursor < 0 {
+		return
+	}
+	// Keep the header above the first row visible too
+	if p.cursor-1 < p.scroll {
+		p.scroll = max(0, p.cursor-1)
+	}
+	if p.cursor >= p.scroll+pickerVisibleRows {
+		p.scroll = p.cursor - pickerVisibleRows + 1
+	}
+}
+
+// fuzzyScore matches every space separated term of query as a
+// subsequence of target. Matches at the start of a segment and runs of
+// consecutive letters score higher.
+func fuzzyScore(query, target string) (int, bool) {
+	target = strings.ToLower(target)
+	total := 0
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		score, ok := termScore(term, target)
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func termScore(term, target string) (int, bool) {
+	if strings.HasPrefix(target, term) {
+		return 100 + len(term)*10, true
+	}
+	if strings.Contains(target, term) {
+		return 50 + len(term)*5, true
+	}
+
+	score, t, previous := 0, 0, -2
+	for _, r := range term {
+		found := false
+		for ; t < len(target); t++ {
+			if rune(target[t]) != r {
+				continue
+			}
+			score++
+			if t == previous+1 {
+				score += 5
+			}
+			if t == 0 || !unicode.IsLetter(rune(target[t-1])) && !unicode.IsDigit(rune(target[t-1])) {
+				score += 8
+			}
+			previous = t
+			t++
+			found = true
+			break
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+// Contains reports whether point is over the picker, open or closed
+func (p *InstrumentPicker) Contains(point rl.Vector2) bool {
+	return rl.CheckCollisionPointRec(point, p.Rect) || (p.IsOpen && rl.CheckCollisionPointRec(point, p.panelRect()))
+}
+
+func (p *InstrumentPicker) panelRect() rl.Rectangle {
+	return rl.Rectangle{
+		X:      p.Rect.X,
+		Y:      p.Rect.Y + p.Rect.Height,
+		Width:  p.Rect.Width,
+		Height: 30 + pickerRowHeight*pickerVisibleRows + 4,
+	}
+}
+
+// rowRect is the screen rectangle of visible row i
+func (p *InstrumentPicker) rowRect(i int) rl.Rectangle {
+	panel := p.panelRect()
+	return rl.Rectangle{
+		X:      panel.X + 1,
+		Y:      panel.Y + 30 + float32(i-p.scroll)*pickerRowHeight,
+		Width:  panel.Width - 2,
+		Height: pickerRowHeight,
+	}
+}
+
+// trimLastRune drops the last character of s, whole even when it takes
+// more than one byte
+func trimLastRune(s string) string {
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
+
+func (p *InstrumentPicker) Update() {
+	mousePos := rl.GetMousePosition()
+	clicked := rl.IsMouseButtonPressed(rl.MouseLeftButton)
+	ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+
+	if !p.IsOpen {
+		if (clicked && rl.CheckCollisionPointRec(mousePos, p.Rect)) || (ctrl && rl.IsKeyPressed(rl.KeyK)) {
+			p.open()
+		}
+		return
+	}
+
+	// Clicking outside or on the box again closes it
+	if (clicked && (!p.Contains(mousePos) || rl.CheckCollisionPointRec(mousePos, p.Rect))) || rl.IsKeyPressed(rl.KeyEscape) {
+		p.close()
+		return
+	}
+
+	if p.version != p.registry.Version() {
+		p.rebuild()
+	}
+
+	// Typing edits the query
+	changed := false
+	for r := rl.GetCharPressed(); r != 0; r = rl.GetCharPressed() {
+		if !ctrl && unicode.IsPrint(rune(r)) {
+			p.query += string(rune(r))
+			changed = true
+		}
+	}
+	if (rl.IsKeyPressed(rl.KeyBackspace) || rl.IsKeyPressedRepeat(rl.KeyBackspace)) && p.query != "" {
+		p.query = trimLastRune(p.query)
+		changed = true
+	}
+	if changed {
+		p.rebuild()
+	}
+
+	switch {
+	case rl.IsKeyPressed(rl.KeyDown) || rl.IsKeyPressedRepeat(rl.KeyDown):
+		p.moveCursor(1)
+	case rl.IsKeyPressed(rl.KeyUp) || rl.IsKeyPressedRepeat(rl.KeyUp):
+		p.moveCursor(-1)
+	case rl.IsKeyPressed(rl.KeyPageDown):
+		p.moveCursor(pickerVisibleRows)
+	case rl.IsKeyPressed(rl.KeyPageUp):
+		p.moveCursor(-pickerVisibleRows)
+	case rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeyKpEnter):
+		if p.cursor >= 0 {
+			p.Select(p.rows[p.cursor].instrument)
+		}
+		return
+	}
+
+	// Wheel scrolls the list, clicking a row picks it
+	if rl.CheckCollisionPointRec(mousePos, p.panelRect()) {
+		if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+			p.scroll = max(0, min(len(p.rows)-pickerVisibleRows, p.scroll-int(wheel)*3))
+		}
+		for i := p.scroll; i < min(len(p.rows), p.scroll+pickerVisibleRows); i++ {
+			if p.rows[i].header == "" && rl.CheckCollisionPointRec(mousePos, p.rowRect(i)) {
+				p.cursor = i
+				if clicked {
+					p.Select(p.rows[i].instrument)
+				}
+				break
+			}
+		}
+	}
+}
+
+func (p *InstrumentPicker) Draw() {
+	// Closed box shows the selected instrument
+	rl.DrawRectangleRec(p.Rect, colors.ColorPanelBg)
+	rl.DrawRectangleLinesEx(p.Rect, 1, colors.ColorBorder)
+	rl.DrawTextEx(p.Font, p.Selected, rl.Vector2{X: p.Rect.X + 10, Y: p.Rect.Y + 5}, 16, 1, colors.ColorText)
+	hint := "Ctrl+K"
+	hintSize := rl.MeasureTextEx(p.Font, hint, 14, 1)
+	rl.DrawTextEx(p.Font, hint,
+		rl.Vector2{X: p.Rect.X + p.Rect.Width - hintSize.X - 8, Y: p.Rect.Y + 6}, 14, 1, colors.ColorSubtext)
+	rl.DrawTextEx(p.Font, "Instrument", rl.Vector2{X: p.Rect.X, Y: p.Rect.Y - 20}, 16, 1, colors.ColorSubtext)
+
+	if !p.IsOpen {
+		return
+	}
+
+	panel := p.panelRect()
+	rl.DrawRectangleRec(panel, colors.ColorPanelBg)
+	rl.DrawRectangleLinesEx(panel, 1, colors.ColorBorder)
+
+	// Search box with a blinking caret
+	search := rl.Rectangle{X: panel.X + 4, Y: panel.Y + 4, Width: panel.Width - 8, Height: 22}
+	rl.DrawRectangleRec(search, colors.ColorBackground)
+	text, textColor := p.query, colors.ColorText
+	if text == "" {
+		text, textColor = "Search instruments...", colors.ColorSubtext
+	}
+	rl.DrawTextEx(p.Font, text, rl.Vector2{X: search.X + 6, Y: search.Y + 3}, 16, 1, textColor)
+	if int(rl.GetTime()*2)%2 == 0 {
+		caretX := search.X + 6 + rl.MeasureTextEx(p.Font, p.query, 16, 1).X
+		rl.DrawLine(int32(caretX), int32(search.Y+3), int32(caretX), int32(search.Y+19), colors.ColorText)
+	}
+
+	if len(p.rows) == 0 {
+		rl.DrawTextEx(p.Font, "No matches", rl.Vector2{X: panel.X + 10, Y: panel.Y + 34}, 16, 1, colors.ColorSubtext)
+		return
+	}
+
+	for i := p.scroll; i < min(len(p.rows), p.scroll+pickerVisibleRows); i++ {
+		row := p.rows[i]
+		rect := p.rowRect(i)
+		if row.header != "" {
+			rl.DrawTextEx(p.Font, row.header, rl.Vector2{X: rect.X + 6, Y: rect.Y + 4}, 14, 1, colors.ColorSubtext)
+			continue
+		}
+		if i == p.cursor {
+			rl.DrawRectangleRec(rect, colors.ColorHeaderBg)
+		}
+		color := colors.ColorText
+		if row.instrument == p.Selected {
+			color = colors.ColorGreen
+		}
+		rl.DrawTextEx(p.Font, row.instrument, rl.Vector2{X: rect.X + 16, Y: rect.Y + 3}, 16, 1, color)
+	}
+
+	// Scroll position on the right edge
+	if len(p.rows) > pickerVisibleRows {
+		track := pickerRowHeight * pickerVisibleRows
+		thumb := max(16, track*pickerVisibleRows/float32(len(p.rows)))
+		y := panel.Y + 30 + (track-thumb)*float32(p.scroll)/float32(len(p.rows)-pickerVisibleRows)
+		rl.DrawRectangleRec(rl.Rectangle{X: panel.X + panel.Width - 5, Y: y, Width: 3, Height: thumb}, colors.ColorBorder)
+	}
+
+	count := fmt.Sprintf("%d", p.matched)
+	countSize := rl.MeasureTextEx(p.Font, count, 14, 1)
+	rl.DrawTextEx(p.Font, count,
+		rl.Vector2{X: search.X + search.Width - countSize.X - 6, Y: search.Y + 4}, 14, 1, colors.ColorSubtext)
+}
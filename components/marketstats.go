@@ -0,0 +1,91 @@
+package components
+
+import (
+	"fmt"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/models"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// statRow is one label and value of the market stats panel
+type statRow struct {
+	label string
+	value string
+	color rl.Color
+}
+
+// signColor colors positive values green and negative ones red
+func signColor(value float64) rl.Color {
+	switch {
+	case value > 0:
+		return colors.ColorGreen
+	case value < 0:
+		return colors.ColorRed
+	}
+	return colors.ColorText
+}
+
+// marketStatRows lists the ticker's summary, skipping what does not apply
+// to the instrument, like funding on dated futures
+func marketStatRows(ticker *models.Ticker, spec *models.Instrument) []statRow {
+	text := colors.ColorText
+	rows := []statRow{
+		{"Last", spec.FormatPrice(ticker.LastPrice), text},
+		{"Mark", spec.FormatPrice(ticker.MarkPrice), text},
+		{"Index", spec.FormatPrice(ticker.IndexPrice), text},
+		{"Basis", fmt.Sprintf("%s (%+.3f%%)", spec.FormatPrice(ticker.Basis()), ticker.BasisPct()), signColor(ticker.Basis())},
+	}
+	if spec != nil && spec.Kind == "future" && !spec.Perpetual() {
+		annualized := ticker.AnnualizedBasisPct(spec.Expiry())
+		rows = append(rows, statRow{"Basis (ann.)", fmt.Sprintf("%+.2f%%", annualized), signColor(annualized)})
+	}
+
+	stats := ticker.Stats
+	rows = append(rows,
+		statRow{"24h High", spec.FormatPrice(stats.High), text},
+		statRow{"24h Low", spec.FormatPrice(stats.Low), text},
+		statRow{"24h Change", fmt.Sprintf("%+.2f%%", stats.PriceChange), signColor(stats.PriceChange)},
+		statRow{"24h Volume", fmt.Sprintf("%s (%s)", spec.FormatAmount(stats.Volume), models.FormatNotional(stats.VolumeUSD)), text},
+		statRow{"Open Interest", spec.FormatAmount(ticker.OpenInterest), text},
+	)
+
+	if spec == nil || spec.Perpetual() {
+		rows = append(rows,
+			statRow{"Funding", fmt.Sprintf("%+.4f%%", ticker.CurrentFunding*100), signColor(ticker.CurrentFunding)},
+			statRow{"Funding 8h", fmt.Sprintf("%+.4f%%", ticker.Funding8h*100), signColor(ticker.Funding8h)},
+			statRow{"Funding (ann.)", fmt.Sprintf("%+.2f%%", ticker.AnnualizedFundingPct()), signColor(ticker.Funding8h)},
+		)
+	}
+	if ticker.EstDeliveryPrice > 0 {
+		rows = append(rows, statRow{"Est. Delivery", spec.FormatPrice(ticker.EstDeliveryPrice), text})
+	}
+	if ticker.SettlementPrice > 0 {
+		rows = append(rows, statRow{"Settlement", spec.FormatPrice(ticker.SettlementPrice), text})
+	}
+	return rows
+}
+
+func RenderMarketStats(w *models.Window) {
+	ticker, ok := w.Data.(*models.Ticker)
+	if !ok || ticker == nil {
+		drawPlaceholder(w, "Loading ticker...")
+		return
+	}
+
+	rows := marketStatRows(ticker, w.Spec)
+	rowSpacing := float32(20)
+
+	contentHeight := float32(10) + float32(len(rows))*rowSpacing
+	w.MaxScroll = max(0, contentHeight-(w.Rect.Height-30))
+	w.ScrollPosition = min(w.ScrollPosition, w.MaxScroll)
+
+	labelX := w.Rect.X + w.Padding
+	valueX := w.Rect.X + w.Padding + 130
+	y := w.Rect.Y + 35 - w.ScrollPosition
+	for _, row := range rows {
+		rl.DrawTextEx(w.Font, row.label, rl.Vector2{X: labelX, Y: y}, 16, 1, colors.ColorSubtext)
+		rl.DrawTextEx(w.Font, row.value, rl.Vector2{X: valueX, Y: y}, 16, 1, row.color)
+		y += rowSpacing
+	}
+}
@@ -0,0 +1,365 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/registry"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const optionsRowHeight = float32(20)
+
+// chainRow is a strike with its call and put, either of which may be missing
+type chainRow struct {
+	strike float64
+	call   *models.Instrument
+	put    *models.Instrument
+}
+
+// optionColumn is a column on one side of the chain
+type optionColumn struct {
+	title string
+	text  func(ticker *models.Ticker, spec *models.Instrument) string
+}
+
+func greekColumn(title string, value func(*models.Greeks) float64, format string) optionColumn {
+	return optionColumn{title, func(ticker *models.Ticker, _ *models.Instrument) string {
+		if ticker.Greeks == nil {
+			return "-"
+		}
+		return fmt.Sprintf(format, value(ticker.Greeks))
+	}}
+}
+
+func quoteColumn(title string, price func(*models.Ticker) float64) optionColumn {
+	return optionColumn{title, func(ticker *models.Ticker, spec *models.Instrument) string {
+		if price(ticker) == 0 {
+			return "-"
+		}
+		return spec.FormatPrice(price(ticker))
+	}}
+}
+
+// putColumns run from the strike outwards; calls mirror them
+var putColumns = []optionColumn{
+	quoteColumn("Bid", func(t *models.Ticker) float64 { return t.BestBidPrice }),
+	quoteColumn("Ask", func(t *models.Ticker) float64 { return t.BestAskPrice }),
+	{"IV", func(t *models.Ticker, _ *models.Instrument) string { return fmt.Sprintf("%.1f", t.MarkIV) }},
+	greekColumn("Delta", func(g *models.Greeks) float64 { return g.Delta }, "%.2f"),
+	greekColumn("Gamma", func(g *models.Greeks) float64 { return g.Gamma }, "%.5f"),
+	greekColumn("Vega", func(g *models.Greeks) float64 { return g.Vega }, "%.2f"),
+	greekColumn("Theta", func(g *models.Greeks) float64 { return g.Theta }, "%.2f"),
+	{"OI", func(t *models.Ticker, spec *models.Instrument) string { return spec.FormatAmount(t.OpenInterest) }},
+}
+
+// optionCurrencies lists the currencies with listed options
+func optionCurrencies(instruments []*models.Instrument) []string {
+	var currencies []string
+	for _, instrument := range instruments {
+		if instrument.Kind == "option" && !slices.Contains(currencies, instrument.BaseCurrency) {
+			currencies = append(currencies, instrument.BaseCurrency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// optionExpiries lists the expiries of a currency's options, nearest first
+func optionExpiries(instruments []*models.Instrument, currency string) []int64 {
+	var expiries []int64
+	for _, instrument := range instruments {
+		if instrument.Kind == "option" && instrument.BaseCurrency == currency &&
+			!slices.Contains(expiries, instrument.ExpirationTimestamp) {
+			expiries = append(expiries, instrument.ExpirationTimestamp)
+		}
+	}
+	slices.Sort(expiries)
+	return expiries
+}
+
+// chainRows pairs the calls and puts of an expiry by strike, lowest first
+func chainRows(instruments []*models.Instrument, currency string, expiry int64) []chainRow {
+	byStrike := make(map[float64]*chainRow)
+	for _, instrument := range instruments {
+		if instrument.Kind != "option" || instrument.BaseCurrency != currency || instrument.ExpirationTimestamp != expiry {
+			continue
+		}
+		row, ok := byStrike[instrument.Strike]
+		if !ok {
+			row = &chainRow{strike: instrument.Strike}
+			byStrike[instrument.Strike] = row
+		}
+		if instrument.OptionType == "call" {
+			row.call = instrument
+		} else {
+			row.put = instrument
+		}
+	}
+
+	rows := make([]chainRow, 0, len(byStrike))
+	for _, row := range byStrike {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].strike < rows[j].strike
+	})
+	return rows
+}
+
+// atmIndex returns the row whose strike is closest to the underlying
+func atmIndex(rows []chainRow, underlying float64) int {
+	best := -1
+	for i, row := range rows {
+		if best < 0 || math.Abs(row.strike-underlying) < math.Abs(rows[best].strike-underlying) {
+			best = i
+		}
+	}
+	return best
+}
+
+// expiryLabel formats an expiry the way Deribit names it, as in 27DEC24
+func expiryLabel(expiry int64) string {
+	return strings.ToUpper(time.UnixMilli(expiry).UTC().Format("2Jan06"))
+}
+
+// OptionsChain lists the options of one currency and expiry, calls on the
+// left and puts on the right of the strikes, from the option tickers.
+// Currency and expiry tabs come from the instrument registry, and only the
+// tickers of the strikes on screen are followed.
+type OptionsChain struct {
+	store    *marketdata.Store
+	registry *registry.Registry
+
+	currency string
+	expiry   int64
+
+	// currencies, expiries and rows are rebuilt when the registry,
+	// currency or expiry change
+	version    uint64
+	currencies []string
+	expiries   []int64
+	rows       []chainRow
+	// centered is set once the view was scrolled to the ATM strike
+	centered bool
+	// visible reports the options on screen
+	visible visibleReporter
+}
+
+func NewOptionsChain(store *marketdata.Store, instruments *registry.Registry, currency string) *OptionsChain {
+	return &OptionsChain{
+		store:    store,
+		registry: instruments,
+		currency: currency,
+	}
+}
+
+// SetOnVisibleHandler sets the callback for when the options on screen
+// change, used to follow only their tickers
+func (c *OptionsChain) SetOnVisibleHandler(handler func([]string)) {
+	c.visible.handler = handler
+}
+
+func (c *OptionsChain) selectExpiry(currency string, expiry int64) {
+	c.currency = currency
+	c.expiry = expiry
+	c.version = 0
+	c.centered = false
+}
+
+// sync rebuilds the rows when the registry changed, keeping the expiry
+// when it is still listed
+func (c *OptionsChain) sync() {
+	version := c.registry.Version()
+	if version == c.version && c.rows != nil {
+		return
+	}
+	c.version = version
+
+	all := c.registry.All()
+	c.currencies = optionCurrencies(all)
+	if len(c.currencies) > 0 && !slices.Contains(c.currencies, c.currency) {
+		c.currency = c.currencies[0]
+	}
+	c.expiries = optionExpiries(all, c.currency)
+	if len(c.expiries) > 0 && !slices.Contains(c.expiries, c.expiry) {
+		c.expiry = c.expiries[0]
+		c.centered = false
+	}
+	c.rows = chainRows(all, c.currency, c.expiry)
+}
+
+// drawTabs draws a row of tabs wrapping within width, highlighting those
+// active, and returns the index clicked, or -1, and the height used
+func drawTabs(font rl.Font, x, y, width float32, labels []string, active func(i int) bool) (int, float32) {
+	mousePos := rl.GetMousePosition()
+	clicked := -1
+	tabX, tabY := x, y
+	for i, label := range labels {
+		size := rl.MeasureTextEx(font, label, 14, 1)
+		rect := rl.Rectangle{X: tabX, Y: tabY, Width: size.X + 12, Height: 20}
+		if rect.X+rect.Width > x+width && tabX > x {
+			tabX, tabY = x, tabY+24
+			rect.X, rect.Y = tabX, tabY
+		}
+
+		background, textColor := colors.ColorHeaderBg, colors.ColorSubtext
+		if active(i) {
+			background, textColor = colors.ColorBorder, colors.ColorText
+		}
+		rl.DrawRectangleRec(rect, background)
+		rl.DrawRectangleLinesEx(rect, 1, colors.ColorBorder)
+		rl.DrawTextEx(font, label, rl.Vector2{X: rect.X + 6, Y: rect.Y + 3}, 14, 1, textColor)
+		if models.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(mousePos, rect) {
+			clicked = i
+		}
+		tabX += rect.Width + 4
+	}
+	return clicked, tabY + 24 - y
+}
+
+func (c *OptionsChain) Render(w *models.Window) {
+	c.sync()
+	currencies := c.currencies
+	if len(currencies) == 0 {
+		drawPlaceholder(w, "Loading instruments...")
+		return
+	}
+
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 35
+	width := w.Rect.Width - w.Padding*2
+
+	// Currency tabs, then expiry tabs of the currency
+	clicked, height := drawTabs(w.Font, x, y, width, currencies, func(i int) bool {
+		return currencies[i] == c.currency
+	})
+	if clicked >= 0 && currencies[clicked] != c.currency {
+		// The expiry is reset to the nearest listed for the new currency
+		c.selectExpiry(currencies[clicked], 0)
+		c.sync()
+	}
+	y += height
+	expiries := c.expiries
+	labels := make([]string, len(expiries))
+	for i, expiry := range expiries {
+		labels[i] = expiryLabel(expiry)
+	}
+	clicked, height = drawTabs(w.Font, x, y, width, labels, func(i int) bool {
+		return expiries[i] == c.expiry
+	})
+	if clicked >= 0 && expiries[clicked] != c.expiry {
+		c.selectExpiry(c.currency, expiries[clicked])
+		c.sync()
+	}
+	y += height
+	w.Title = fmt.Sprintf("deribit %s options - %s", c.currency, expiryLabel(c.expiry))
+
+	// The underlying is the same for the whole expiry, take it from any ticker
+	underlying := 0.0
+	tickers := make([][2]*models.Ticker, len(c.rows))
+	for i, row := range c.rows {
+		for side, option := range []*models.Instrument{row.call, row.put} {
+			if option == nil {
+				continue
+			}
+			ticker, _ := c.store.Ticker(option.InstrumentName)
+			tickers[i][side] = ticker
+			if ticker != nil && ticker.UnderlyingPrice > 0 {
+				underlying = ticker.UnderlyingPrice
+			}
+		}
+	}
+	atm := atmIndex(c.rows, underlying)
+
+	// Calls mirror the put columns around the strike
+	columnWidth := width / float32(len(putColumns)*2+1)
+	strikeX := x + columnWidth*float32(len(putColumns))
+	callX := func(i int) float32 { return strikeX - columnWidth*float32(i+1) }
+	putX := func(i int) float32 { return strikeX + columnWidth*float32(i+1) }
+	drawCell := func(text string, cellX, cellY float32, color rl.Color) {
+		// Right aligned within the cell
+		size := rl.MeasureTextEx(w.Font, text, 14, 1)
+		rl.DrawTextEx(w.Font, text, rl.Vector2{X: cellX + columnWidth - size.X - 4, Y: cellY + 3}, 14, 1, color)
+	}
+
+	rl.DrawTextEx(w.Font, "Calls", rl.Vector2{X: x, Y: y}, 16, 1, colors.ColorGreen)
+	putsSize := rl.MeasureTextEx(w.Font, "Puts", 16, 1)
+	rl.DrawTextEx(w.Font, "Puts", rl.Vector2{X: x + width - putsSize.X, Y: y}, 16, 1, colors.ColorRed)
+	if underlying > 0 {
+		label := fmt.Sprintf("Underlying %.2f", underlying)
+		size := rl.MeasureTextEx(w.Font, label, 14, 1)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: x + (width-size.X)/2, Y: y + 1}, 14, 1, colors.ColorSubtext)
+	}
+	y += 20
+	for i, column := range putColumns {
+		drawCell(column.title, callX(i), y, colors.ColorSubtext)
+		drawCell(column.title, putX(i), y, colors.ColorSubtext)
+	}
+	drawCell("Strike", strikeX, y, colors.ColorSubtext)
+	y += optionsRowHeight + 2
+
+	listTop := y
+	listHeight := w.Rect.Y + w.Rect.Height - w.Padding - listTop
+	w.MaxScroll = max(0, float32(len(c.rows))*optionsRowHeight-listHeight)
+	if !c.centered && underlying > 0 {
+		// Open on the ATM strike
+		w.ScrollPosition = float32(atm)*optionsRowHeight - listHeight/2
+		c.centered = true
+	}
+	w.ScrollPosition = max(0, min(w.ScrollPosition, w.MaxScroll))
+	first := int(w.ScrollPosition / optionsRowHeight)
+	last := min(len(c.rows), first+int(listHeight/optionsRowHeight)+2)
+
+	itm := rl.NewColor(colors.ColorBorder.R, colors.ColorBorder.G, colors.ColorBorder.B, 90)
+	var visible []string
+	rl.BeginScissorMode(int32(w.Rect.X), int32(listTop), int32(w.Rect.Width), int32(listHeight))
+	for i := first; i < last; i++ {
+		row := c.rows[i]
+		rowY := listTop + float32(i)*optionsRowHeight - w.ScrollPosition
+
+		// In the money calls are below the underlying, puts above it
+		if underlying > 0 && row.strike < underlying {
+			rl.DrawRectangleRec(rl.Rectangle{X: x, Y: rowY, Width: strikeX - x, Height: optionsRowHeight}, itm)
+		}
+		if underlying > 0 && row.strike > underlying {
+			rl.DrawRectangleRec(rl.Rectangle{X: strikeX + columnWidth, Y: rowY, Width: x + width - strikeX - columnWidth, Height: optionsRowHeight}, itm)
+		}
+
+		strikeColor := colors.ColorText
+		if i == atm && underlying > 0 {
+			rl.DrawRectangleRec(rl.Rectangle{X: strikeX, Y: rowY, Width: columnWidth, Height: optionsRowHeight}, colors.ColorHeaderBg)
+			strikeColor = colors.ColorYellow
+		}
+		drawCell(fmt.Sprintf("%g", row.strike), strikeX, rowY, strikeColor)
+
+		for side, option := range []*models.Instrument{row.call, row.put} {
+			if option == nil {
+				continue
+			}
+			visible = append(visible, option.InstrumentName)
+			ticker := tickers[i][side]
+			if ticker == nil {
+				continue
+			}
+			for j, column := range putColumns {
+				cellX := putX(j)
+				if side == 0 {
+					cellX = callX(j)
+				}
+				drawCell(column.text(ticker, option), cellX, rowY, colors.ColorText)
+			}
+		}
+	}
+	rl.EndScissorMode()
+	w.ClipContent()
+
+	c.visible.report(visible)
+}
@@ -2,14 +2,239 @@ package components
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"time"
 
+	"github.com/adityanagar10/trader/analytics"
 	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
 	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/pricing"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-func RenderOrderBook(w *models.Window) {
+// groupingMultipliers are the 1-2-5 steps offered for grouping, each
+// scaled by powers of ten and kept when it is a multiple of the tick size
+var groupingMultipliers = []float64{1, 2, 5}
+
+// analyticsStripHeight is the height of the analytics strip at the bottom
+const analyticsStripHeight = float32(62)
+
+// bookDepths are the numbers of levels per side the ladder can show, the
+// depths Deribit cuts a book to. Zero is the full book the other windows
+// use too.
+var bookDepths = []int{1, 10, 20, 0}
+
+// OrderBook is the order book ladder. Each window has its own grouping
+// step, which defaults to the instrument's tick size, its own depth and
+// can show amounts as USD notional. The depth is passed to Deribit
+// through onDepth, which follows the book at that depth.
+type OrderBook struct {
+	store       *marketdata.Store
+	instrument  string
+	tickSize    float64
+	steps       []float64
+	picker      *Dropdown
+	depthPicker *Dropdown
+	showUSD     bool
+
+	// reportedDepth is the depth last passed to onDepth, -1 before that
+	reportedDepth int
+	onDepth       func(depth int)
+}
+
+func NewOrderBook(store *marketdata.Store, font rl.Font) *OrderBook {
+	depths := make([]string, len(bookDepths))
+	for i, depth := range bookDepths {
+		depths[i] = fmt.Sprintf("Depth %d", depth)
+		if depth == 0 {
+			depths[i] = "Full book"
+		}
+	}
+
+	ob := &OrderBook{
+		store:         store,
+		picker:        NewDropdown(0, 0, 130, []string{""}, "", font),
+		depthPicker:   NewDropdown(0, 0, 110, depths, "", font),
+		reportedDepth: -1,
+	}
+	ob.SetDepth(20)
+	return ob
+}
+
+// SetOnDepthHandler sets the callback for when the depth changes, used to
+// follow the book at that depth. Zero is the full book.
+func (ob *OrderBook) SetOnDepthHandler(handler func(int)) {
+	ob.onDepth = handler
+}
+
+// SetDepth sets the number of levels shown per side, rounded up to one of
+// the offered depths. Zero, or more than Deribit cuts a book to, shows
+// the full book.
+func (ob *OrderBook) SetDepth(depth int) {
+	for i, option := range bookDepths {
+		ob.depthPicker.SelectedIndex = i
+		if depth > 0 && option >= depth {
+			return
+		}
+	}
+}
+
+// Depth returns the number of levels shown per side, zero for all of them
+func (ob *OrderBook) Depth() int {
+	return bookDepths[ob.depthPicker.SelectedIndex]
+}
+
+// reportDepth passes a changed depth to onDepth
+func (ob *OrderBook) reportDepth() {
+	if depth := ob.Depth(); depth != ob.reportedDepth {
+		ob.reportedDepth = depth
+		if ob.onDepth != nil {
+			ob.onDepth(depth)
+		}
+	}
+}
+
+// groupingDecades bounds how many powers of ten above the tick size are
+// searched for grouping steps
+const groupingDecades = 6
+
+// groupingSteps lists up to count grouping steps starting at the tick
+// size. A tick size like 0.3 that no round step is a multiple of gets
+// 1-2-5 multiples of the tick size instead.
+func groupingSteps(tickSize float64, count int) []float64 {
+	steps := []float64{tickSize}
+	limit := tickSize * math.Pow(10, groupingDecades)
+	for magnitude := math.Pow(10, math.Floor(math.Log10(tickSize))); len(steps) < count && magnitude <= limit; magnitude *= 10 {
+		for _, multiplier := range groupingMultipliers {
+			step := multiplier * magnitude
+			ticks := step / tickSize
+			if step > tickSize && len(steps) < count && math.Abs(ticks-math.Round(ticks)) < 1e-9 {
+				steps = append(steps, step)
+			}
+		}
+	}
+	if len(steps) > 1 {
+		return steps
+	}
+	for magnitude := 1.0; len(steps) < count && magnitude <= math.Pow(10, groupingDecades); magnitude *= 10 {
+		for _, multiplier := range groupingMultipliers {
+			if step := multiplier * magnitude * tickSize; step > tickSize && len(steps) < count {
+				steps = append(steps, step)
+			}
+		}
+	}
+	return steps
+}
+
+// groupLevels merges levels into buckets of step. Bids are bucketed down
+// and asks up, so a grouped level never looks better than the orders in
+// it. Levels must be sorted from the best price, as Deribit sends them.
+func groupLevels(levels [][]float64, step float64, roundUp bool) [][]float64 {
+	if step <= 0 {
+		return levels
+	}
+
+	grouped := make([][]float64, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		// The epsilon keeps prices already on a bucket edge in their bucket
+		var price float64
+		if roundUp {
+			price = math.Ceil(level[0]/step-1e-9) * step
+		} else {
+			price = math.Floor(level[0]/step+1e-9) * step
+		}
+
+		if last := len(grouped) - 1; last >= 0 && grouped[last][0] == price {
+			grouped[last][1] += level[1]
+			continue
+		}
+		grouped = append(grouped, []float64{price, level[1]})
+	}
+	return grouped
+}
+
+// updateSteps picks the grouping steps for a new instrument once its tick
+// size is known, starting ungrouped at the tick size
+func (ob *OrderBook) updateSteps(instrument string) {
+	var tickSize float64
+	if spec := ob.store.Instrument(instrument); spec != nil {
+		tickSize = spec.TickSize
+	}
+	if instrument == ob.instrument && tickSize == ob.tickSize {
+		return
+	}
+	ob.instrument = instrument
+	ob.tickSize = tickSize
+
+	ob.steps = nil
+	ob.picker.Options = []string{""}
+	if tickSize > 0 {
+		ob.steps = groupingSteps(tickSize, 10)
+		ob.picker.Options = make([]string, len(ob.steps))
+		for i, step := range ob.steps {
+			ob.picker.Options[i] = "Group " + strconv.FormatFloat(step, 'f', -1, 64)
+		}
+	}
+	ob.picker.SelectedIndex = 0
+	ob.picker.IsOpen = false
+}
+
+// step returns the selected grouping step, zero before the tick size is known
+func (ob *OrderBook) step() float64 {
+	if len(ob.steps) == 0 {
+		return 0
+	}
+	return ob.steps[ob.picker.SelectedIndex]
+}
+
+func (ob *OrderBook) Render(w *models.Window) {
+	ob.updateSteps(w.Instrument)
+
+	// Controls on the header row, from the right: USD toggle, depth and
+	// grouping. They stay put while the ladder scrolls and are drawn last
+	// so open options cover the ladder.
+	usdRect := rl.Rectangle{X: w.Rect.X + w.Rect.Width - w.Padding - 44, Y: w.Rect.Y + 30, Width: 44, Height: 26}
+	ob.depthPicker.Rect.X = usdRect.X - 6 - ob.depthPicker.Rect.Width
+	ob.depthPicker.Rect.Y = w.Rect.Y + 30
+	ob.picker.Rect.X = ob.depthPicker.Rect.X - 6 - ob.picker.Rect.Width
+	ob.picker.Rect.Y = w.Rect.Y + 30
+
+	ob.depthPicker.Update()
+	defer ob.depthPicker.Draw()
+	if len(ob.steps) > 0 {
+		ob.picker.Update()
+		defer ob.picker.Draw()
+	}
+
+	usdColor := colors.ColorSubtext
+	if ob.showUSD {
+		usdColor = colors.ColorText
+	}
+	defer func() {
+		rl.DrawRectangleRec(usdRect, colors.ColorPanelBg)
+		rl.DrawRectangleLinesEx(usdRect, 1, colors.ColorBorder)
+		rl.DrawTextEx(w.Font, "USD", rl.Vector2{X: usdRect.X + 8, Y: usdRect.Y + 5}, 16, 1, usdColor)
+	}()
+	if models.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(rl.GetMousePosition(), usdRect) {
+		ob.showUSD = !ob.showUSD
+	}
+	ob.reportDepth()
+
+	// The window's feed is the full book, a depth Deribit cuts to comes
+	// from its own book
 	orderBook, ok := w.Data.(*models.OrderBookResult)
+	if depth := ob.Depth(); depth > 0 {
+		if snapshot, found := ob.store.Get(w.Instrument, marketdata.BookDepthKind(depth)); found {
+			orderBook, ok = snapshot.Value.(*models.OrderBookResult)
+		} else {
+			orderBook, ok = nil, false
+		}
+	}
 	if !ok || orderBook == nil {
 		// Render placeholder if no data available
 		rl.DrawTextEx(
@@ -22,18 +247,43 @@ func RenderOrderBook(w *models.Window) {
 		return
 	}
 
+	if orderBook.Resyncing {
+		// The book is being rebuilt after a missed update, don't draw stale levels
+		rl.DrawTextEx(
+			w.Font,
+			fmt.Sprintf("Resyncing order book... (resync #%d)", orderBook.Resyncs),
+			rl.Vector2{X: w.Rect.X + w.Padding, Y: w.Rect.Y + 35},
+			16,
+			1,
+			colors.ColorRed)
+		return
+	}
+
+	// Group both sides before picking the levels to show
+	asks := groupLevels(orderBook.Asks, ob.step(), true)
+	bids := groupLevels(orderBook.Bids, ob.step(), false)
+
 	// Use fixed-width columns for terminal style appearance
-	numAsks := len(orderBook.Asks)
-	if numAsks > 20 {
-		numAsks = 20
+	numAsks, numBids := len(asks), len(bids)
+	if depth := ob.Depth(); depth > 0 {
+		numAsks = min(numAsks, depth)
+		numBids = min(numBids, depth)
 	}
 
-	numBids := len(orderBook.Bids)
-	if numBids > 20 {
-		numBids = 20
+	// Amounts in the instrument's units, or their USD value
+	spec := w.Spec
+	formatAmount := spec.FormatAmount
+	amountOf := func(level []float64) float64 {
+		return level[1]
+	}
+	if ob.showUSD {
+		formatAmount = models.FormatNotional
+		amountOf = func(level []float64) float64 {
+			return spec.Notional(level[1], level[0])
+		}
 	}
 
-	contentHeight := float32(45 + (numAsks+numBids)*20 + 30) // Header + rows + spread row
+	contentHeight := float32(45+(numAsks+numBids)*20+30) + analyticsStripHeight // Header + rows + spread row + analytics
 	w.MaxScroll = contentHeight - (w.Rect.Height - 30)
 	if w.MaxScroll < 0 {
 		w.MaxScroll = 0
@@ -56,15 +306,16 @@ func RenderOrderBook(w *models.Window) {
 	rl.DrawTextEx(w.Font, "Total", rl.Vector2{X: totalX, Y: startY}, 16, 1, headerColor)
 	startY += rowSpacing + 5
 
-	// Calculate max volume for visualization (subtle volume bars like in image 2)
+	// Calculate max volume for visualization (subtle volume bars like in image 2),
+	// over the grouped levels that are shown
 	maxVolume := 0.0
-	for _, ask := range orderBook.Asks {
-		if len(ask) >= 2 && ask[1] > maxVolume {
+	for _, ask := range asks[:numAsks] {
+		if ask[1] > maxVolume {
 			maxVolume = ask[1]
 		}
 	}
-	for _, bid := range orderBook.Bids {
-		if len(bid) >= 2 && bid[1] > maxVolume {
+	for _, bid := range bids[:numBids] {
+		if bid[1] > maxVolume {
 			maxVolume = bid[1]
 		}
 	}
@@ -72,12 +323,19 @@ func RenderOrderBook(w *models.Window) {
 	// Display asks (from lowest to highest) - RED
 	totalAsks := 0.0
 
+	// Totals accumulate from the best ask outwards, and asks are drawn
+	// furthest first
+	askTotals := make([]float64, numAsks)
+	for i := 0; i < numAsks; i++ {
+		totalAsks += amountOf(asks[i])
+		askTotals[i] = totalAsks
+	}
+
 	for i := numAsks - 1; i >= 0; i-- {
-		ask := orderBook.Asks[i]
+		ask := asks[i]
 		if len(ask) >= 2 {
 			price := ask[0]
 			amount := ask[1]
-			totalAsks += amount
 
 			// Draw subtle volume bar (similar to image 2)
 			barWidth := (amount / maxVolume) * float64((w.Rect.Width - 50 - w.Padding*2))
@@ -90,9 +348,9 @@ func RenderOrderBook(w *models.Window) {
 			rl.DrawRectangleRec(barRect, rl.NewColor(229, 78, 103, 40)) // Very subtle red background
 
 			// Draw text with monospaced font
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.2f", price), rl.Vector2{X: priceX, Y: startY}, 16, 1, colors.ColorRed)
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.4f", amount), rl.Vector2{X: amountX, Y: startY}, 16, 1, colors.ColorRed)
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.4f", totalAsks), rl.Vector2{X: totalX, Y: startY}, 16, 1, colors.ColorRed)
+			rl.DrawTextEx(w.Font, spec.FormatPrice(price), rl.Vector2{X: priceX, Y: startY}, 16, 1, colors.ColorRed)
+			rl.DrawTextEx(w.Font, formatAmount(amountOf(ask)), rl.Vector2{X: amountX, Y: startY}, 16, 1, colors.ColorRed)
+			rl.DrawTextEx(w.Font, formatAmount(askTotals[i]), rl.Vector2{X: totalX, Y: startY}, 16, 1, colors.ColorRed)
 
 			startY += rowSpacing
 		}
@@ -120,22 +378,35 @@ func RenderOrderBook(w *models.Window) {
 
 	rl.DrawTextEx(
 		w.Font,
-		fmt.Sprintf("Spread: %.2f (%.4f%%)", spread, spreadPct),
+		fmt.Sprintf("Spread: %s (%.4f%%)", spec.FormatPrice(spread), spreadPct),
 		rl.Vector2{X: w.Rect.X + w.Padding + 5, Y: startY + 2},
 		16,
 		1,
 		colors.ColorText)
+
+	// Resync counter on the right of the spread row
+	if orderBook.Resyncs > 0 {
+		resyncText := fmt.Sprintf("resyncs: %d", orderBook.Resyncs)
+		resyncSize := rl.MeasureTextEx(w.Font, resyncText, 16, 1)
+		rl.DrawTextEx(
+			w.Font,
+			resyncText,
+			rl.Vector2{X: w.Rect.X + w.Rect.Width - w.Padding - 5 - resyncSize.X, Y: startY + 2},
+			16,
+			1,
+			colors.ColorSubtext)
+	}
 	startY += rowSpacing + 5
 
 	// Draw bids (buy orders) - GREEN (similar to image 2)
 	totalBids := 0.0
 
 	for i := 0; i < numBids; i++ {
-		bid := orderBook.Bids[i]
+		bid := bids[i]
 		if len(bid) >= 2 {
 			price := bid[0]
 			amount := bid[1]
-			totalBids += amount
+			totalBids += amountOf(bid)
 
 			// Draw subtle volume bar
 			barWidth := (amount / maxVolume) * float64((w.Rect.Width - 50 - w.Padding*2))
@@ -148,11 +419,94 @@ func RenderOrderBook(w *models.Window) {
 			rl.DrawRectangleRec(barRect, rl.NewColor(75, 201, 155, 40)) // Very subtle green background
 
 			// Draw text with monospaced font
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.2f", price), rl.Vector2{X: priceX, Y: startY}, 16, 1, colors.ColorGreen)
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.4f", amount), rl.Vector2{X: amountX, Y: startY}, 16, 1, colors.ColorGreen)
-			rl.DrawTextEx(w.Font, fmt.Sprintf("%.4f", totalBids), rl.Vector2{X: totalX, Y: startY}, 16, 1, colors.ColorGreen)
+			rl.DrawTextEx(w.Font, spec.FormatPrice(price), rl.Vector2{X: priceX, Y: startY}, 16, 1, colors.ColorGreen)
+			rl.DrawTextEx(w.Font, formatAmount(amountOf(bid)), rl.Vector2{X: amountX, Y: startY}, 16, 1, colors.ColorGreen)
+			rl.DrawTextEx(w.Font, formatAmount(totalBids), rl.Vector2{X: totalX, Y: startY}, 16, 1, colors.ColorGreen)
 
 			startY += rowSpacing
 		}
 	}
+
+	if metrics, _ := ob.store.BookMetrics(w.Instrument); metrics != nil {
+		drawAnalyticsStrip(w, metrics, ob.optionQuote(w, orderBook))
+	}
+}
+
+// optionQuote solves our own IVs of an option's book, nil for other
+// instruments or before the ticker brings the underlying price and mark
+func (ob *OrderBook) optionQuote(w *models.Window, orderBook *models.OrderBookResult) *pricing.Quote {
+	if w.Spec == nil || w.Spec.Kind != "option" {
+		return nil
+	}
+	ticker, _ := ob.store.Ticker(w.Instrument)
+	if ticker == nil || ticker.UnderlyingPrice <= 0 {
+		return nil
+	}
+
+	book := *orderBook
+	book.MarkPrice = ticker.MarkPrice
+	quote, err := pricing.QuoteBook(&book, w.Spec, ticker.UnderlyingPrice, time.Now())
+	if err != nil {
+		return nil
+	}
+	return &quote
+}
+
+// drawAnalyticsStrip shows the book analytics in three lines pinned to the
+// bottom of the window, over the ladder. Options also show the IVs of
+// quote.
+func drawAnalyticsStrip(w *models.Window, metrics *analytics.Metrics, quote *pricing.Quote) {
+	strip := rl.Rectangle{
+		X:      w.Rect.X + 1,
+		Y:      w.Rect.Y + w.Rect.Height - analyticsStripHeight - 1,
+		Width:  w.Rect.Width - 2,
+		Height: analyticsStripHeight,
+	}
+	rl.DrawRectangleRec(strip, colors.ColorHeaderBg)
+	rl.DrawLine(int32(strip.X), int32(strip.Y), int32(strip.X+strip.Width), int32(strip.Y), colors.ColorBorder)
+
+	x := strip.X + w.Padding
+	y := strip.Y + 4
+	item := func(label, value string, color rl.Color) {
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: x, Y: y}, 14, 1, colors.ColorSubtext)
+		x += rl.MeasureTextEx(w.Font, label, 14, 1).X + 4
+		rl.DrawTextEx(w.Font, value, rl.Vector2{X: x, Y: y}, 14, 1, color)
+		x += rl.MeasureTextEx(w.Font, value, 14, 1).X + 12
+	}
+
+	imbalanceColor := colors.ColorGreen
+	if metrics.Imbalance < 0 {
+		imbalanceColor = colors.ColorRed
+	}
+	item(fmt.Sprintf("Imb%d", analytics.DefaultConfig.Levels), fmt.Sprintf("%+.2f", metrics.Imbalance), imbalanceColor)
+	item("Micro", w.Spec.FormatPrice(metrics.Microprice), colors.ColorText)
+	item("WMid", w.Spec.FormatPrice(metrics.WeightedMid), colors.ColorText)
+	if quote != nil {
+		item("IV", fmt.Sprintf("%.1f/%.1f", quote.BidIV*100, quote.AskIV*100), colors.ColorText)
+		item("Mark IV", fmt.Sprintf("%.1f", quote.MarkIV*100), colors.ColorText)
+		item("Delta", fmt.Sprintf("%.2f", quote.Greeks.Delta), colors.ColorText)
+	}
+
+	// Depth within each band, bid/ask in USD
+	x = strip.X + w.Padding
+	y += 18
+	for _, depth := range metrics.Depth {
+		item(fmt.Sprintf("%g%%", depth.Band*100),
+			models.FormatNotional(depth.BidNotional)+"/"+models.FormatNotional(depth.AskNotional), colors.ColorText)
+	}
+
+	// Market order estimates
+	x = strip.X + w.Padding
+	y += 18
+	for _, side := range []struct {
+		label string
+		fill  analytics.Fill
+		color rl.Color
+	}{{"Buy", metrics.Buy, colors.ColorGreen}, {"Sell", metrics.Sell, colors.ColorRed}} {
+		value := fmt.Sprintf("avg %s %.1fbps", w.Spec.FormatPrice(side.fill.AvgPrice), side.fill.Slippage)
+		if !side.fill.Complete() {
+			value += " (only " + models.FormatNotional(side.fill.Filled) + ")"
+		}
+		item(side.label+" "+models.FormatNotional(side.fill.Notional), value, side.color)
+	}
 }
@@ -1,15 +1,13 @@
 package components
 
 import (
-	"fmt"
-
 	colors "github.com/adityanagar10/trader/constants"
 	"github.com/adityanagar10/trader/models"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func RenderRecentTrades(w *models.Window) {
-	// Trade data could come from websocket similar to order book
+	// Newest trade first, published by the client on every print
 	trades, ok := w.Data.([]models.Trade)
 	if !ok || trades == nil {
 		rl.DrawTextEx(
@@ -22,17 +20,33 @@ func RenderRecentTrades(w *models.Window) {
 		return
 	}
 
-	startY := w.Rect.Y + 35 - w.ScrollPosition
 	rowSpacing := float32(20)
 
-	// Draw column headers
+	// New prints are added at the top, so staying scrolled to the top
+	// follows the tape
+	contentHeight := float32(45) + float32(len(trades))*rowSpacing
+	w.MaxScroll = contentHeight - (w.Rect.Height - 30)
+	if w.MaxScroll < 0 {
+		w.MaxScroll = 0
+	}
+	if w.ScrollPosition > w.MaxScroll {
+		w.ScrollPosition = w.MaxScroll
+	}
+
+	startY := w.Rect.Y + 35 - w.ScrollPosition
+
+	// Draw column headers; the price column leaves room for long prices
+	// before the tick direction
 	priceX := w.Rect.X + w.Padding
-	amountX := w.Rect.X + w.Padding + 120
-	timeX := w.Rect.X + w.Padding + 220
+	tickX := w.Rect.X + w.Padding + 130
+	amountX := w.Rect.X + w.Padding + 150
+	timeX := w.Rect.X + w.Padding + 260
+	liquidationX := w.Rect.X + w.Padding + 370
 
 	rl.DrawTextEx(w.Font, "Price", rl.Vector2{X: priceX, Y: startY}, 16, 1, colors.ColorSubtext)
 	rl.DrawTextEx(w.Font, "Amount", rl.Vector2{X: amountX, Y: startY}, 16, 1, colors.ColorSubtext)
 	rl.DrawTextEx(w.Font, "Time", rl.Vector2{X: timeX, Y: startY}, 16, 1, colors.ColorSubtext)
+	rl.DrawTextEx(w.Font, "Liq", rl.Vector2{X: liquidationX, Y: startY}, 16, 1, colors.ColorSubtext)
 	startY += rowSpacing + 5
 
 	// Display trades
@@ -43,13 +57,26 @@ func RenderRecentTrades(w *models.Window) {
 			textColor = colors.ColorRed
 		}
 
-		rl.DrawTextEx(w.Font, fmt.Sprintf("%.2f", trade.Price),
+		// Tick direction next to the price
+		tickText, tickColor := "-", colors.ColorRed
+		if trade.Uptick() {
+			tickText, tickColor = "+", colors.ColorGreen
+		}
+
+		rl.DrawTextEx(w.Font, w.Spec.FormatPrice(trade.Price),
 			rl.Vector2{X: priceX, Y: startY}, 16, 1, textColor)
-		rl.DrawTextEx(w.Font, fmt.Sprintf("%.4f", trade.Amount),
+		rl.DrawTextEx(w.Font, tickText,
+			rl.Vector2{X: tickX, Y: startY}, 16, 1, tickColor)
+		rl.DrawTextEx(w.Font, w.Spec.FormatAmount(trade.Amount),
 			rl.Vector2{X: amountX, Y: startY}, 16, 1, textColor)
-		rl.DrawTextEx(w.Font, trade.Timestamp,
+		rl.DrawTextEx(w.Font, trade.Timestamp.Format("15:04:05.000"),
 			rl.Vector2{X: timeX, Y: startY}, 16, 1, colors.ColorSubtext)
 
+		if trade.Liquidation != "" {
+			rl.DrawTextEx(w.Font, "LIQ",
+				rl.Vector2{X: liquidationX, Y: startY}, 16, 1, colors.ColorHighlight)
+		}
+
 		startY += rowSpacing
 	}
 }
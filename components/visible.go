@@ -0,0 +1,23 @@
+package components
+
+import "slices"
+
+// visibleReporter tells a handler which instruments a component shows,
+// once per change, so the client follows only their tickers
+type visibleReporter struct {
+	// last is the set last reported, sorted; nil before the first report
+	last    []string
+	handler func(instruments []string)
+}
+
+func (r *visibleReporter) report(visible []string) {
+	current := append([]string{}, visible...)
+	slices.Sort(current)
+	if r.last != nil && slices.Equal(current, r.last) {
+		return
+	}
+	r.last = current
+	if r.handler != nil {
+		r.handler(current)
+	}
+}
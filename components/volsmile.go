@@ -0,0 +1,435 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/pricing"
+	"github.com/adityanagar10/trader/registry"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Smile x axes
+const (
+	smileAxisStrike = iota
+	smileAxisDelta
+	smileAxisMoneyness
+)
+
+const (
+	// smileDefaultExpiries are how many of the nearest expiries are
+	// overlaid until others are picked
+	smileDefaultExpiries = 4
+	smileMaxSnapshots    = 5
+)
+
+// smileColors tell the overlaid expiries apart
+var smileColors = []rl.Color{
+	colors.ColorBlue,
+	colors.ColorOrange,
+	colors.ColorPurple,
+	colors.ColorYellow,
+	colors.ColorCyan,
+	colors.ColorPink,
+	colors.ColorGreen,
+	colors.ColorRed,
+}
+
+// smileSnapshot is the state of every smile of a currency at one time
+type smileSnapshot struct {
+	currency string
+	taken    time.Time
+	smiles   []pricing.Smile
+}
+
+// curvePoint is a point of a plotted curve with what the hover shows
+type curvePoint struct {
+	x, y  float64
+	label string
+}
+
+// VolSmile plots implied volatility against strike, delta or moneyness
+// for the expiries of a currency, or ATM volatility against time to
+// expiry. It reads the marks of every option on the currency's index,
+// which the client follows through onIndex.
+type VolSmile struct {
+	store    *marketdata.Store
+	registry *registry.Registry
+
+	currency string
+	index    string
+	// currencies and the index are looked up again when the registry
+	// changes
+	registryVersion uint64
+	currencies      []string
+	// shown are the expiries overlaid, picked from the expiry tabs
+	shown map[int64]bool
+
+	marksVersion uint64
+	smiles       []pricing.Smile
+	snapshots    []smileSnapshot
+
+	axisPicker *Dropdown
+	viewPicker *Dropdown
+
+	onIndex func(index string)
+}
+
+func NewVolSmile(store *marketdata.Store, instruments *registry.Registry, currency string, font rl.Font) *VolSmile {
+	return &VolSmile{
+		store:      store,
+		registry:   instruments,
+		currency:   currency,
+		shown:      make(map[int64]bool),
+		axisPicker: NewDropdown(0, 0, 120, []string{"Strike", "Delta", "Moneyness"}, "", font),
+		viewPicker: NewDropdown(0, 0, 110, []string{"Smile", "Term"}, "", font),
+	}
+}
+
+// SetOnIndexHandler sets the callback for when the panel needs the option
+// marks of another price index
+func (v *VolSmile) SetOnIndexHandler(handler func(string)) {
+	v.onIndex = handler
+}
+
+// sync follows the index of the currency's options and rebuilds the
+// smiles when new marks come in
+func (v *VolSmile) sync() {
+	if version := v.registry.Version(); version != v.registryVersion || v.currencies == nil {
+		v.registryVersion = version
+		v.currencies = optionCurrencies(v.registry.All())
+		if len(v.currencies) > 0 && !slices.Contains(v.currencies, v.currency) {
+			v.currency = v.currencies[0]
+		}
+		v.syncIndex()
+	}
+
+	marks, version := v.store.OptionMarks(v.index)
+	if version == v.marksVersion {
+		return
+	}
+	v.marksVersion = version
+	v.smiles = pricing.BuildSmiles(marks, v.registry.Get, time.Now())
+
+	// Overlay the nearest expiries until any shown one is listed
+	for _, smile := range v.smiles {
+		if v.shown[smile.Expiry.UnixMilli()] {
+			return
+		}
+	}
+	clear(v.shown)
+	for _, smile := range v.smiles[:min(smileDefaultExpiries, len(v.smiles))] {
+		v.shown[smile.Expiry.UnixMilli()] = true
+	}
+}
+
+// syncIndex follows the index of the currency's options
+func (v *VolSmile) syncIndex() {
+	index := ""
+	for _, instrument := range v.registry.All() {
+		if instrument.Kind == "option" && instrument.BaseCurrency == v.currency {
+			index = instrument.Index()
+			break
+		}
+	}
+	if index != v.index {
+		v.index = index
+		v.marksVersion = 0
+		v.smiles = nil
+		if v.onIndex != nil {
+			v.onIndex(index)
+		}
+	}
+}
+
+func (v *VolSmile) takeSnapshot() {
+	v.snapshots = append(v.snapshots, smileSnapshot{
+		currency: v.currency,
+		taken:    time.Now(),
+		smiles:   v.smiles,
+	})
+	if len(v.snapshots) > smileMaxSnapshots {
+		v.snapshots = v.snapshots[1:]
+	}
+}
+
+// smileCurve maps a smile onto the x axis
+func smileCurve(smile pricing.Smile, axis int) []curvePoint {
+	points := make([]curvePoint, len(smile.Points))
+	for i, point := range smile.Points {
+		x := point.Strike
+		switch axis {
+		case smileAxisDelta:
+			x = point.Delta
+		case smileAxisMoneyness:
+			x = point.Moneyness * 100
+		}
+		points[i] = curvePoint{
+			x: x,
+			y: point.IV * 100,
+			label: fmt.Sprintf("%s  K %g  IV %.1f%%  Delta %.2f",
+				expiryLabel(smile.Expiry.UnixMilli()), point.Strike, point.IV*100, point.Delta),
+		}
+	}
+	return points
+}
+
+// termCurve is ATM volatility against days to expiry
+func termCurve(smiles []pricing.Smile, now time.Time) []curvePoint {
+	points := make([]curvePoint, 0, len(smiles))
+	for _, smile := range smiles {
+		days := smile.Expiry.Sub(now).Hours() / 24
+		atm := smile.ATMVol() * 100
+		points = append(points, curvePoint{
+			x:     days,
+			y:     atm,
+			label: fmt.Sprintf("%s  %.1fd  ATM IV %.1f%%", expiryLabel(smile.Expiry.UnixMilli()), days, atm),
+		})
+	}
+	return points
+}
+
+func (v *VolSmile) Render(w *models.Window) {
+	v.sync()
+	currencies := v.currencies
+	w.Title = fmt.Sprintf("deribit %s - Volatility", v.currency)
+
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 30
+	width := w.Rect.Width - w.Padding*2
+	mousePos := rl.GetMousePosition()
+	clicked := models.IsMouseButtonPressed(rl.MouseLeftButton)
+
+	// View and axis pickers on the right, drawn last to open over the plot
+	v.viewPicker.Rect.X = x + width - v.viewPicker.Rect.Width
+	v.viewPicker.Rect.Y = y
+	v.axisPicker.Rect.X = v.viewPicker.Rect.X - 6 - v.axisPicker.Rect.Width
+	v.axisPicker.Rect.Y = y
+	v.viewPicker.Update()
+	term := v.viewPicker.SelectedIndex == 1
+	if !term {
+		v.axisPicker.Update()
+		defer v.axisPicker.Draw()
+	}
+	defer v.viewPicker.Draw()
+
+	// Snapshot and clear buttons left of the pickers
+	buttonX := v.axisPicker.Rect.X
+	button := func(label string) bool {
+		size := rl.MeasureTextEx(w.Font, label, 14, 1)
+		buttonX -= size.X + 12 + 6
+		rect := rl.Rectangle{X: buttonX, Y: y + 3, Width: size.X + 12, Height: 20}
+		rl.DrawRectangleRec(rect, colors.ColorHeaderBg)
+		rl.DrawRectangleLinesEx(rect, 1, colors.ColorBorder)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: rect.X + 6, Y: rect.Y + 3}, 14, 1, colors.ColorText)
+		return clicked && rl.CheckCollisionPointRec(mousePos, rect)
+	}
+	if button("Snapshot") && len(v.smiles) > 0 {
+		v.takeSnapshot()
+	}
+	if len(v.snapshots) > 0 && button("Clear") {
+		v.snapshots = nil
+	}
+
+	// Currency tabs, then the expiries to overlay
+	tabsWidth := buttonX - 6 - x
+	index, height := drawTabs(w.Font, x, y+3, tabsWidth, currencies, func(i int) bool {
+		return currencies[i] == v.currency
+	})
+	if index >= 0 && currencies[index] != v.currency {
+		v.currency = currencies[index]
+		clear(v.shown)
+		v.syncIndex()
+		v.sync()
+	}
+	y += max(height, 30)
+
+	if len(v.smiles) == 0 {
+		drawPlaceholder(w, "Waiting for option marks...")
+		return
+	}
+
+	if !term {
+		labels := make([]string, len(v.smiles))
+		for i, smile := range v.smiles {
+			labels[i] = expiryLabel(smile.Expiry.UnixMilli())
+		}
+		index, height := drawTabs(w.Font, x, y, width, labels, func(i int) bool {
+			return v.shown[v.smiles[i].Expiry.UnixMilli()]
+		})
+		if index >= 0 {
+			expiry := v.smiles[index].Expiry.UnixMilli()
+			v.shown[expiry] = !v.shown[expiry]
+		}
+		y += height
+	}
+
+	plot := rl.Rectangle{
+		X:      x,
+		Y:      y + 4,
+		Width:  width - graphAxisWidth,
+		Height: w.Rect.Y + w.Rect.Height - w.Padding - graphTimeAxisHeight - y - 4,
+	}
+	if plot.Width < 50 || plot.Height < 30 {
+		return
+	}
+
+	// Curves of the current marks, then the snapshots faded behind them
+	type curve struct {
+		points []curvePoint
+		color  rl.Color
+		label  string
+		faded  bool
+	}
+	var curves []curve
+	now := time.Now()
+	if term {
+		curves = append(curves, curve{points: termCurve(v.smiles, now), color: colors.ColorBlue, label: "ATM IV"})
+	} else {
+		for i, smile := range v.smiles {
+			if v.shown[smile.Expiry.UnixMilli()] {
+				color := smileColors[i%len(smileColors)]
+				curves = append(curves, curve{points: smileCurve(smile, v.axisPicker.SelectedIndex), color: color, label: expiryLabel(smile.Expiry.UnixMilli())})
+			}
+		}
+	}
+	for _, snapshot := range v.snapshots {
+		if snapshot.currency != v.currency {
+			continue
+		}
+		label := "Snapshot " + snapshot.taken.Format("15:04:05")
+		if term {
+			curves = append(curves, curve{points: termCurve(snapshot.smiles, now), color: colors.ColorBlue, label: label, faded: true})
+			continue
+		}
+		for i, smile := range v.smiles {
+			for _, old := range snapshot.smiles {
+				if old.Expiry.Equal(smile.Expiry) && v.shown[smile.Expiry.UnixMilli()] {
+					curves = append(curves, curve{points: smileCurve(old, v.axisPicker.SelectedIndex), color: smileColors[i%len(smileColors)], faded: true})
+				}
+			}
+		}
+	}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, c := range curves {
+		for _, point := range c.points {
+			minX, maxX = math.Min(minX, point.x), math.Max(maxX, point.x)
+			minY, maxY = math.Min(minY, point.y), math.Max(maxY, point.y)
+		}
+	}
+	if math.IsInf(minX, 0) {
+		drawPlaceholder(w, "Pick an expiry to plot")
+		return
+	}
+	if maxX == minX {
+		minX, maxX = minX-1, maxX+1
+	}
+	padding := math.Max((maxY-minY)*0.1, 1)
+	minY, maxY = minY-padding, maxY+padding
+
+	// Delta falls as the strike rises, flip it so the wings stay in place
+	reversed := !term && v.axisPicker.SelectedIndex == smileAxisDelta
+	toScreen := func(point curvePoint) rl.Vector2 {
+		t := (point.x - minX) / (maxX - minX)
+		if reversed {
+			t = 1 - t
+		}
+		return rl.Vector2{
+			X: plot.X + float32(t)*plot.Width,
+			Y: plot.Y + plot.Height - float32((point.y-minY)/(maxY-minY))*plot.Height,
+		}
+	}
+
+	rl.DrawRectangleRec(plot, colors.ColorChartBg)
+	for i := 0; i <= 4; i++ {
+		value := minY + (maxY-minY)*float64(i)/4
+		lineY := toScreen(curvePoint{x: minX, y: value}).Y
+		rl.DrawLine(int32(plot.X), int32(lineY), int32(plot.X+plot.Width), int32(lineY), colors.ColorBorder)
+		rl.DrawTextEx(w.Font, fmt.Sprintf("%.1f%%", value),
+			rl.Vector2{X: plot.X + plot.Width + 6, Y: lineY - 7}, 14, 1, colors.ColorSubtext)
+	}
+	for i := 0; i <= 4; i++ {
+		value := minX + (maxX-minX)*float64(i)/4
+		var label string
+		switch {
+		case term:
+			label = fmt.Sprintf("%.0fd", value)
+		case v.axisPicker.SelectedIndex == smileAxisDelta:
+			label = fmt.Sprintf("%.2f", value)
+		case v.axisPicker.SelectedIndex == smileAxisMoneyness:
+			label = fmt.Sprintf("%+.0f%%", value)
+		default:
+			label = fmt.Sprintf("%g", math.Round(value))
+		}
+		labelSize := rl.MeasureTextEx(w.Font, label, 14, 1)
+		labelX := toScreen(curvePoint{x: value, y: minY}).X - labelSize.X/2
+		labelX = min(max(labelX, plot.X), plot.X+plot.Width-labelSize.X)
+		rl.DrawTextEx(w.Font, label, rl.Vector2{X: labelX, Y: plot.Y + plot.Height + 3}, 14, 1, colors.ColorSubtext)
+	}
+
+	// Faded snapshots first so the live curves draw over them
+	var hovered *curvePoint
+	hoverDistance := float32(12)
+	rl.BeginScissorMode(int32(plot.X), int32(plot.Y), int32(plot.Width), int32(plot.Height))
+	for _, faded := range []bool{true, false} {
+		for _, c := range curves {
+			if c.faded != faded {
+				continue
+			}
+			color, thickness := c.color, float32(2)
+			if faded {
+				color, thickness = rl.NewColor(color.R, color.G, color.B, 90), 1
+			}
+			for i, point := range c.points {
+				screen := toScreen(point)
+				if i > 0 {
+					rl.DrawLineEx(toScreen(c.points[i-1]), screen, thickness, color)
+				}
+				if faded {
+					continue
+				}
+				rl.DrawCircleV(screen, 2.5, color)
+				if distance := rl.Vector2Distance(screen, mousePos); distance < hoverDistance {
+					hoverDistance = distance
+					hovered = &c.points[i]
+				}
+			}
+		}
+	}
+	rl.EndScissorMode()
+	w.ClipContent()
+
+	// Legend in the top left of the plot
+	legendY := plot.Y + 4
+	for _, c := range curves {
+		if c.label == "" {
+			continue
+		}
+		color := c.color
+		if c.faded {
+			color = rl.NewColor(color.R, color.G, color.B, 120)
+		}
+		rl.DrawRectangleRec(rl.Rectangle{X: plot.X + 6, Y: legendY + 4, Width: 10, Height: 3}, color)
+		rl.DrawTextEx(w.Font, c.label, rl.Vector2{X: plot.X + 20, Y: legendY}, 14, 1, color)
+		legendY += 16
+	}
+
+	if hovered != nil {
+		screen := toScreen(*hovered)
+		size := rl.MeasureTextEx(w.Font, hovered.label, 14, 1)
+		box := rl.Rectangle{X: screen.X + 10, Y: screen.Y - 26, Width: size.X + 12, Height: 20}
+		if box.X+box.Width > plot.X+plot.Width {
+			box.X = screen.X - 10 - box.Width
+		}
+		rl.DrawRectangleRec(box, colors.ColorHeaderBg)
+		rl.DrawRectangleLinesEx(box, 1, colors.ColorBorder)
+		rl.DrawTextEx(w.Font, hovered.label, rl.Vector2{X: box.X + 6, Y: box.Y + 3}, 14, 1, colors.ColorText)
+	}
+}
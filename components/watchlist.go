@@ -0,0 +1,326 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+
+	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/settings"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	watchlistFile      = "watchlist.json"
+	watchlistRowHeight = float32(20)
+	// watchlistUnsorted keeps the instruments in the order they were pinned
+	watchlistUnsorted = -1
+)
+
+// watchlistSettings is the persisted state of the watchlist
+type watchlistSettings struct {
+	Instruments []string `json:"instruments"`
+	SortColumn  int      `json:"sort_column"`
+	Descending  bool     `json:"descending"`
+}
+
+// watchRow is a pinned instrument with its latest ticker, nil until the
+// first one arrives
+type watchRow struct {
+	instrument string
+	ticker     *models.Ticker
+	spec       *models.Instrument
+}
+
+// watchColumn is a column of the watchlist. Columns with a value sort by
+// it, the instrument column by name.
+type watchColumn struct {
+	title string
+	x     float32
+	value func(row watchRow) float64
+	text  func(row watchRow) string
+}
+
+var watchColumns = []watchColumn{
+	{title: "Instrument", x: 0},
+	{
+		title: "Last", x: 150,
+		value: func(row watchRow) float64 { return row.ticker.LastPrice },
+		text:  func(row watchRow) string { return row.spec.FormatPrice(row.ticker.LastPrice) },
+	},
+	{
+		title: "Bid", x: 240,
+		value: func(row watchRow) float64 { return row.ticker.BestBidPrice },
+		text:  func(row watchRow) string { return row.spec.FormatPrice(row.ticker.BestBidPrice) },
+	},
+	{
+		title: "Ask", x: 330,
+		value: func(row watchRow) float64 { return row.ticker.BestAskPrice },
+		text:  func(row watchRow) string { return row.spec.FormatPrice(row.ticker.BestAskPrice) },
+	},
+	{
+		title: "24h %", x: 420,
+		value: func(row watchRow) float64 { return row.ticker.Stats.PriceChange },
+		text:  func(row watchRow) string { return fmt.Sprintf("%+.2f%%", row.ticker.Stats.PriceChange) },
+	},
+	{
+		title: "Volume", x: 490,
+		value: func(row watchRow) float64 { return row.ticker.Stats.VolumeUSD },
+		text:  func(row watchRow) string { return models.FormatNotional(row.ticker.Stats.VolumeUSD) },
+	},
+	{
+		// Open interest in USD so instruments of any size compare
+		title: "OI", x: 570,
+		value: func(row watchRow) float64 {
+			return row.spec.Notional(row.ticker.OpenInterest, row.ticker.MarkPrice)
+		},
+		text: func(row watchRow) string {
+			return models.FormatNotional(row.spec.Notional(row.ticker.OpenInterest, row.ticker.MarkPrice))
+		},
+	},
+}
+
+// Watchlist shows live tickers of pinned instruments. Clicking a row focuses
+// its instrument, clicking a column title sorts by it. Only the tickers of
+// the rows on screen are followed; scrolled out rows keep their last values.
+type Watchlist struct {
+	store *marketdata.Store
+
+	instruments []string
+	sortColumn  int
+	descending  bool
+	// focused is the instrument the other windows show, pinned by the
+	// Pin button
+	focused string
+	// visible reports the rows on screen
+	visible visibleReporter
+
+	onSelect func(instrument string)
+}
+
+func NewWatchlist(store *marketdata.Store, focused string) *Watchlist {
+	saved := watchlistSettings{
+		Instruments: []string{"BTC-PERPETUAL", "ETH-PERPETUAL"},
+		SortColumn:  watchlistUnsorted,
+	}
+	if err := settings.Load(watchlistFile, &saved); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to load watchlist: %v", err)
+	}
+	if saved.SortColumn < watchlistUnsorted || saved.SortColumn >= len(watchColumns) {
+		saved.SortColumn = watchlistUnsorted
+	}
+	return &Watchlist{
+		store:       store,
+		instruments: saved.Instruments,
+		sortColumn:  saved.SortColumn,
+		descending:  saved.Descending,
+		focused:     focused,
+	}
+}
+
+// SetOnSelectHandler sets the callback for when a row is clicked
+func (l *Watchlist) SetOnSelectHandler(handler func(string)) {
+	l.onSelect = handler
+}
+
+// SetOnVisibleHandler sets the callback for when the rows on screen
+// change, used to follow only their tickers
+func (l *Watchlist) SetOnVisibleHandler(handler func([]string)) {
+	l.visible.handler = handler
+}
+
+// Focus marks the instrument the other windows show
+func (l *Watchlist) Focus(instrument string) {
+	l.focused = instrument
+}
+
+// Pin adds an instrument to the end of the list
+func (l *Watchlist) Pin(instrument string) {
+	if slices.Contains(l.instruments, instrument) {
+		return
+	}
+	l.instruments = append(l.instruments, instrument)
+	l.save()
+}
+
+func (l *Watchlist) Unpin(instrument string) {
+	l.instruments = slices.DeleteFunc(l.instruments, func(name string) bool {
+		return name == instrument
+	})
+	l.save()
+}
+
+func (l *Watchlist) save() {
+	saved := watchlistSettings{
+		Instruments: l.instruments,
+		SortColumn:  l.sortColumn,
+		Descending:  l.descending,
+	}
+	if err := settings.Save(watchlistFile, saved); err != nil {
+		log.Printf("Failed to save watchlist: %v", err)
+	}
+}
+
+// sortBy sorts by a column, or flips the order when it already does
+func (l *Watchlist) sortBy(column int) {
+	if column == l.sortColumn {
+		l.descending = !l.descending
+	} else {
+		l.sortColumn = column
+		// Names read A to Z, numbers largest first
+		l.descending = watchColumns[column].value != nil
+	}
+	l.save()
+}
+
+// rows returns the pinned instruments with their tickers, in display order.
+// Rows without a ticker yet sort last either way.
+func (l *Watchlist) rows() []watchRow {
+	rows := make([]watchRow, len(l.instruments))
+	for i, instrument := range l.instruments {
+		ticker, _ := l.store.Ticker(instrument)
+		rows[i] = watchRow{instrument: instrument, ticker: ticker, spec: l.store.Instrument(instrument)}
+	}
+	if l.sortColumn == watchlistUnsorted {
+		return rows
+	}
+
+	column := watchColumns[l.sortColumn]
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if column.value == nil {
+			if l.descending {
+				return a.instrument > b.instrument
+			}
+			return a.instrument < b.instrument
+		}
+		if a.ticker == nil || b.ticker == nil {
+			return a.ticker != nil && b.ticker == nil
+		}
+		if l.descending {
+			return column.value(a) > column.value(b)
+		}
+		return column.value(a) < column.value(b)
+	})
+	return rows
+}
+
+func (l *Watchlist) Render(w *models.Window) {
+	mousePos := rl.GetMousePosition()
+	clicked := models.IsMouseButtonPressed(rl.MouseLeftButton)
+	contentRect := rl.Rectangle{X: w.Rect.X, Y: w.Rect.Y + 30, Width: w.Rect.Width, Height: w.Rect.Height - 30}
+	x := w.Rect.X + w.Padding
+	y := w.Rect.Y + 35
+
+	// Pin button for the focused instrument
+	pinned := slices.Contains(l.instruments, l.focused)
+	pinText := "Pin " + l.focused
+	if pinned {
+		pinText = "Pinned " + l.focused
+	}
+	pinSize := rl.MeasureTextEx(w.Font, pinText, 14, 1)
+	pinRect := rl.Rectangle{X: x, Y: y - 2, Width: pinSize.X + 12, Height: 20}
+	pinColor := colors.ColorText
+	if pinned {
+		pinColor = colors.ColorSubtext
+	}
+	rl.DrawRectangleRec(pinRect, colors.ColorHeaderBg)
+	rl.DrawRectangleLinesEx(pinRect, 1, colors.ColorBorder)
+	rl.DrawTextEx(w.Font, pinText, rl.Vector2{X: pinRect.X + 6, Y: pinRect.Y + 3}, 14, 1, pinColor)
+	if clicked && !pinned && l.focused != "" && rl.CheckCollisionPointRec(mousePos, pinRect) {
+		l.Pin(l.focused)
+	}
+	y += 26
+
+	// Column titles, the sorted one marked with its direction
+	for i, column := range watchColumns {
+		title := column.title
+		switch {
+		case i == l.sortColumn && l.descending:
+			title += " v"
+		case i == l.sortColumn:
+			title += " ^"
+		}
+		titleSize := rl.MeasureTextEx(w.Font, title, 16, 1)
+		titleRect := rl.Rectangle{X: x + column.x, Y: y, Width: titleSize.X, Height: watchlistRowHeight}
+		titleColor := colors.ColorSubtext
+		if rl.CheckCollisionPointRec(mousePos, titleRect) {
+			titleColor = colors.ColorText
+			if clicked {
+				l.sortBy(i)
+			}
+		}
+		rl.DrawTextEx(w.Font, title, rl.Vector2{X: titleRect.X, Y: y}, 16, 1, titleColor)
+	}
+	y += watchlistRowHeight + 5
+
+	rows := l.rows()
+	if len(rows) == 0 {
+		rl.DrawTextEx(w.Font, "Pin instruments to watch them here", rl.Vector2{X: x, Y: y}, 16, 1, colors.ColorSubtext)
+		l.visible.report(nil)
+		return
+	}
+
+	// Rows scroll under the fixed titles
+	listTop := y
+	listHeight := w.Rect.Y + w.Rect.Height - w.Padding - listTop
+	w.MaxScroll = max(0, float32(len(rows))*watchlistRowHeight-listHeight)
+	w.ScrollPosition = min(w.ScrollPosition, w.MaxScroll)
+	first := int(w.ScrollPosition / watchlistRowHeight)
+	last := min(len(rows), first+int(listHeight/watchlistRowHeight)+2)
+
+	visible := make([]string, 0, last-first)
+	rl.BeginScissorMode(int32(w.Rect.X), int32(listTop), int32(w.Rect.Width), int32(listHeight))
+	for i := first; i < last; i++ {
+		row := rows[i]
+		visible = append(visible, row.instrument)
+		rowY := listTop + float32(i)*watchlistRowHeight - w.ScrollPosition
+		rowRect := rl.Rectangle{X: w.Rect.X + 1, Y: rowY, Width: w.Rect.Width - 2, Height: watchlistRowHeight}
+		hovered := rl.CheckCollisionPointRec(mousePos, rowRect) && rl.CheckCollisionPointRec(mousePos, contentRect) && mousePos.Y >= listTop
+
+		if row.instrument == l.focused {
+			rl.DrawRectangleRec(rowRect, colors.ColorHeaderBg)
+		}
+		nameColor := colors.ColorText
+		if hovered {
+			nameColor = colors.ColorHighlight
+		}
+		rl.DrawTextEx(w.Font, row.instrument, rl.Vector2{X: x, Y: rowY + 2}, 16, 1, nameColor)
+
+		if row.ticker == nil {
+			rl.DrawTextEx(w.Font, "-", rl.Vector2{X: x + watchColumns[1].x, Y: rowY + 2}, 16, 1, colors.ColorSubtext)
+		} else {
+			for _, column := range watchColumns[1:] {
+				color := colors.ColorText
+				if column.title == "24h %" {
+					color = signColor(row.ticker.Stats.PriceChange)
+				}
+				rl.DrawTextEx(w.Font, column.text(row), rl.Vector2{X: x + column.x, Y: rowY + 2}, 16, 1, color)
+			}
+		}
+
+		if !hovered {
+			continue
+		}
+
+		// Unpin with the x at the end of the hovered row
+		removeRect := rl.Rectangle{X: w.Rect.X + w.Rect.Width - w.Padding - 14, Y: rowY + 2, Width: 14, Height: 16}
+		rl.DrawTextEx(w.Font, "x", rl.Vector2{X: removeRect.X + 3, Y: removeRect.Y}, 16, 1, colors.ColorRed)
+		if clicked {
+			if rl.CheckCollisionPointRec(mousePos, removeRect) {
+				l.Unpin(row.instrument)
+			} else if row.instrument != l.focused && l.onSelect != nil {
+				l.onSelect(row.instrument)
+			}
+		}
+	}
+	rl.EndScissorMode()
+	w.ClipContent()
+
+	l.visible.report(visible)
+}
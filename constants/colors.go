@@ -14,4 +14,15 @@ var (
 	ColorRed        = rl.NewColor(229, 78, 103, 255)
 	ColorHighlight  = rl.NewColor(255, 255, 255, 255)
 	ColorChartBg    = rl.NewColor(25, 29, 34, 255)
+
+	// Indicator line colors
+	ColorBlue   = rl.NewColor(86, 156, 232, 255)
+	ColorOrange = rl.NewColor(240, 160, 70, 255)
+	ColorPurple = rl.NewColor(170, 120, 230, 255)
+	ColorYellow = rl.NewColor(230, 210, 90, 255)
+	ColorCyan   = rl.NewColor(80, 200, 210, 255)
+	ColorPink   = rl.NewColor(235, 120, 180, 255)
 )
+
+// IndicatorPalette is the order indicator colors are cycled through
+var IndicatorPalette = []rl.Color{ColorBlue, ColorOrange, ColorPurple, ColorYellow, ColorCyan, ColorPink}
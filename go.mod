@@ -2,10 +2,13 @@ module github.com/adityanagar10/trader
 
 go 1.24.1
 
+require (
+	github.com/gen2brain/raylib-go/raylib v0.0.0-20250409052854-a4292f0f0412
+	github.com/gorilla/websocket v1.5.3
+)
+
 require (
 	github.com/ebitengine/purego v0.8.2 // indirect
-	github.com/gen2brain/raylib-go/raylib v0.0.0-20250409052854-a4292f0f0412 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
 	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 )
@@ -0,0 +1,188 @@
+// Package heatmap keeps a rolling history of order book liquidity sampled
+// at a fixed interval, compact enough to hold hours of samples.
+package heatmap
+
+import (
+	"math"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Column is the book at one sample time plus the trades since the
+// previous sample. Sizes are quantized to uint16 relative to the
+// column's largest level, which keeps a column to two bytes per price.
+type Column struct {
+	Time time.Time
+	// Base is the bucket of Sizes[0], Mid the bucket of the mid price
+	Base  int64
+	Mid   int64
+	Sizes []uint16
+	Scale float32
+
+	BuyVolume  float64
+	SellVolume float64
+	// TradePrice is the volume weighted price of the trades, LastPrice the
+	// last one. Both are zero when nothing traded.
+	TradePrice float64
+	LastPrice  float64
+}
+
+// Size returns the resting size in bucket
+func (c *Column) Size(bucket int64) float64 {
+	i := bucket - c.Base
+	if i < 0 || i >= int64(len(c.Sizes)) {
+		return 0
+	}
+	return float64(c.Sizes[i]) * float64(c.Scale)
+}
+
+// Volume is the traded volume during the column
+func (c *Column) Volume() float64 {
+	return c.BuyVolume + c.SellVolume
+}
+
+// History is a ring of columns, the oldest overwritten once it is full
+type History struct {
+	Interval time.Duration
+	// Depth is how many buckets are kept on each side of the mid
+	Depth int
+
+	bucketSize float64
+	columns    []Column
+	start      int
+	count      int
+	lastSample time.Time
+
+	// Trades collected for the next column
+	pending      Column
+	pendingValue float64
+}
+
+// NewHistory keeps capacity columns sampled every interval
+func NewHistory(interval time.Duration, capacity, depth int) *History {
+	return &History{
+		Interval: interval,
+		Depth:    depth,
+		columns:  make([]Column, capacity),
+	}
+}
+
+// Reset drops all columns, the bucket size is picked again from the next
+// book
+func (h *History) Reset() {
+	clear(h.columns)
+	h.start = 0
+	h.count = 0
+	h.bucketSize = 0
+	h.lastSample = time.Time{}
+	h.pending = Column{}
+	h.pendingValue = 0
+}
+
+// BucketSize is the price step of a bucket, zero until the first sample
+func (h *History) BucketSize() float64 {
+	return h.bucketSize
+}
+
+// Bucket returns the bucket holding price
+func (h *History) Bucket(price float64) int64 {
+	return int64(math.Floor(price / h.bucketSize))
+}
+
+// Price returns the lowest price of bucket
+func (h *History) Price(bucket int64) float64 {
+	return float64(bucket) * h.bucketSize
+}
+
+// Len returns the number of columns held
+func (h *History) Len() int {
+	return h.count
+}
+
+// At returns the i-th column, oldest first
+func (h *History) At(i int) *Column {
+	return &h.columns[(h.start+i)%len(h.columns)]
+}
+
+// Due reports whether the next sample should be taken at now
+func (h *History) Due(now time.Time) bool {
+	return now.Sub(h.lastSample) >= h.Interval
+}
+
+// AddTrade counts a trade towards the next column
+func (h *History) AddTrade(trade models.Trade) {
+	if trade.Direction == "sell" {
+		h.pending.SellVolume += trade.Amount
+	} else {
+		h.pending.BuyVolume += trade.Amount
+	}
+	h.pendingValue += trade.Price * trade.Amount
+	h.pending.TradePrice = h.pendingValue / h.pending.Volume()
+	h.pending.LastPrice = trade.Price
+}
+
+// Sample records the book as a new column at now, together with the
+// trades added since the last sample
+func (h *History) Sample(now time.Time, book *models.OrderBookResult) {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return
+	}
+	mid := (book.Bids[0][0] + book.Asks[0][0]) / 2
+	if h.bucketSize == 0 {
+		h.bucketSize = defaultBucketSize(mid)
+	}
+
+	column := h.pending
+	column.Time = now
+	column.Mid = h.Bucket(mid)
+	column.Base = column.Mid - int64(h.Depth)
+
+	// Sum into buckets first, then quantize against the largest one
+	sizes := make([]float64, 2*h.Depth+1)
+	for _, levels := range [][][]float64{book.Bids, book.Asks} {
+		for _, level := range levels {
+			i := h.Bucket(level[0]) - column.Base
+			if i < 0 || i >= int64(len(sizes)) {
+				// Levels are sorted from the mid, the rest are further out
+				break
+			}
+			sizes[i] += level[1]
+		}
+	}
+	maxSize := 0.0
+	for _, size := range sizes {
+		maxSize = math.Max(maxSize, size)
+	}
+	column.Sizes = make([]uint16, len(sizes))
+	if maxSize > 0 {
+		column.Scale = float32(maxSize / math.MaxUint16)
+		for i, size := range sizes {
+			column.Sizes[i] = uint16(math.Round(size / maxSize * math.MaxUint16))
+		}
+	}
+
+	if h.count < len(h.columns) {
+		h.columns[(h.start+h.count)%len(h.columns)] = column
+		h.count++
+	} else {
+		h.columns[h.start] = column
+		h.start = (h.start + 1) % len(h.columns)
+	}
+
+	h.lastSample = now
+	h.pending = Column{}
+	h.pendingValue = 0
+}
+
+// defaultBucketSize picks a round price step of about a basis point of price
+func defaultBucketSize(price float64) float64 {
+	target := price * 0.0001
+	magnitude := math.Pow(10, math.Floor(math.Log10(target)))
+	for _, step := range []float64{1, 2, 5} {
+		if step*magnitude >= target {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
@@ -0,0 +1,166 @@
+package indicators
+
+import (
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// SMA is the simple moving average of closes
+type SMA struct {
+	period int
+	window ring
+}
+
+func NewSMA(period int) *SMA {
+	return &SMA{period: period, window: newRing(period)}
+}
+
+func (s *SMA) Update(candle models.Candle, closed bool) []float64 {
+	return []float64{s.next(candle.Close, closed)}
+}
+
+func (s *SMA) next(value float64, closed bool) float64 {
+	sum, count := s.window.sum+value, s.window.count+1
+	if s.window.full() {
+		sum -= s.window.oldest()
+		count = s.period
+	}
+	if closed {
+		s.window.push(value)
+	}
+	if count < s.period {
+		return nan
+	}
+	return sum / float64(s.period)
+}
+
+func (s *SMA) Reset() {
+	s.window.reset()
+}
+
+// EMA is the exponential moving average of closes, seeded with the SMA of
+// the first period bars
+type EMA struct {
+	period int
+	alpha  float64
+	value  float64
+	seed   float64
+	count  int
+}
+
+func NewEMA(period int) *EMA {
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+func (e *EMA) Update(candle models.Candle, closed bool) []float64 {
+	return []float64{e.next(candle.Close, closed)}
+}
+
+func (e *EMA) next(value float64, closed bool) float64 {
+	if e.count < e.period {
+		seed, count := e.seed+value, e.count+1
+		result := nan
+		if count == e.period {
+			result = seed / float64(e.period)
+		}
+		if closed {
+			e.seed, e.count, e.value = seed, count, result
+		}
+		return result
+	}
+
+	result := e.alpha*value + (1-e.alpha)*e.value
+	if closed {
+		e.value = result
+	}
+	return result
+}
+
+func (e *EMA) Reset() {
+	e.value, e.seed, e.count = 0, 0, 0
+}
+
+// WMA is the linearly weighted moving average of closes, the newest bar
+// weighing period times as much as the oldest
+type WMA struct {
+	period    int
+	window    ring
+	numerator float64
+}
+
+func NewWMA(period int) *WMA {
+	return &WMA{period: period, window: newRing(period)}
+}
+
+func (w *WMA) Update(candle models.Candle, closed bool) []float64 {
+	value := candle.Close
+	divisor := float64(w.period*(w.period+1)) / 2
+
+	var numerator float64
+	switch {
+	case w.window.full():
+		// Every weight drops by one, the oldest falls off, the new value
+		// comes in at full weight
+		numerator = w.numerator + float64(w.period)*value - w.window.sum
+	case w.window.count == w.period-1:
+		// Window fills with this bar, weigh it from scratch once
+		weight := 1.0
+		w.window.each(func(v float64) {
+			numerator += weight * v
+			weight++
+		})
+		numerator += float64(w.period) * value
+	default:
+		if closed {
+			w.window.push(value)
+		}
+		return []float64{nan}
+	}
+
+	if closed {
+		w.numerator = numerator
+		w.window.push(value)
+	}
+	return []float64{numerator / divisor}
+}
+
+func (w *WMA) Reset() {
+	w.window.reset()
+	w.numerator = 0
+}
+
+// VWAP is the volume weighted average typical price, anchored to the
+// start of each UTC day
+type VWAP struct {
+	session     time.Time
+	priceVolume float64
+	volume      float64
+}
+
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+func (v *VWAP) Update(candle models.Candle, closed bool) []float64 {
+	session := candle.Time.UTC().Truncate(24 * time.Hour)
+	priceVolume, volume := v.priceVolume, v.volume
+	if !session.Equal(v.session) {
+		priceVolume, volume = 0, 0
+	}
+
+	priceVolume += typicalPrice(candle) * candle.Volume
+	volume += candle.Volume
+
+	if closed {
+		v.session, v.priceVolume, v.volume = session, priceVolume, volume
+	}
+	if volume == 0 {
+		return []float64{nan}
+	}
+	return []float64{priceVolume / volume}
+}
+
+func (v *VWAP) Reset() {
+	*v = VWAP{}
+}
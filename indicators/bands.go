@@ -0,0 +1,52 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Bollinger bands are the SMA of closes plus and minus Width standard
+// deviations. Values are middle, upper and lower.
+type Bollinger struct {
+	period  int
+	width   float64
+	window  ring
+	squares ring
+}
+
+func NewBollinger(period int, width float64) *Bollinger {
+	return &Bollinger{
+		period:  period,
+		width:   width,
+		window:  newRing(period),
+		squares: newRing(period),
+	}
+}
+
+func (b *Bollinger) Update(candle models.Candle, closed bool) []float64 {
+	value := candle.Close
+	sum, squares, count := b.window.sum+value, b.squares.sum+value*value, b.window.count+1
+	if b.window.full() {
+		sum -= b.window.oldest()
+		squares -= b.squares.oldest()
+		count = b.period
+	}
+	if closed {
+		b.window.push(value)
+		b.squares.push(value * value)
+	}
+	if count < b.period {
+		return []float64{nan, nan, nan}
+	}
+
+	mean := sum / float64(b.period)
+	// Guard against tiny negative variances from rounding
+	deviation := math.Sqrt(math.Max(0, squares/float64(b.period)-mean*mean))
+	return []float64{mean, mean + b.width*deviation, mean - b.width*deviation}
+}
+
+func (b *Bollinger) Reset() {
+	b.window.reset()
+	b.squares.reset()
+}
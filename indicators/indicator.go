@@ -0,0 +1,71 @@
+// Package indicators computes technical indicators one bar at a time, so a
+// chart can keep them current without recomputing the whole series.
+package indicators
+
+import (
+	"math"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// Indicator is fed bars oldest first. A closed bar advances the state for
+// good. An open bar, the one still forming, only computes what the values
+// would be if it closed now, so it can be fed again every time it changes.
+//
+// Values are NaN until the indicator has seen enough bars.
+type Indicator interface {
+	Update(candle models.Candle, closed bool) []float64
+	Reset()
+}
+
+var nan = math.NaN()
+
+// ring keeps the last n values pushed into it and their sum
+type ring struct {
+	values []float64
+	start  int
+	count  int
+	sum    float64
+}
+
+func newRing(n int) ring {
+	return ring{values: make([]float64, n)}
+}
+
+func (r *ring) full() bool {
+	return r.count == len(r.values)
+}
+
+func (r *ring) oldest() float64 {
+	return r.values[r.start]
+}
+
+func (r *ring) push(value float64) {
+	if r.full() {
+		r.sum -= r.values[r.start]
+		r.values[r.start] = value
+		r.start = (r.start + 1) % len(r.values)
+	} else {
+		r.values[(r.start+r.count)%len(r.values)] = value
+		r.count++
+	}
+	r.sum += value
+}
+
+// each visits the values oldest first
+func (r *ring) each(visit func(float64)) {
+	for i := 0; i < r.count; i++ {
+		visit(r.values[(r.start+i)%len(r.values)])
+	}
+}
+
+func (r *ring) reset() {
+	r.start = 0
+	r.count = 0
+	r.sum = 0
+}
+
+// typicalPrice is the (high + low + close) / 3 average of a bar
+func typicalPrice(candle models.Candle) float64 {
+	return (candle.High + candle.Low + candle.Close) / 3
+}
@@ -0,0 +1,200 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// wilder is Wilder's smoothing: a plain average over the first period
+// values, then avg = (avg * (period - 1) + value) / period
+type wilder struct {
+	period  int
+	average float64
+	count   int
+}
+
+func (w *wilder) next(value float64, closed bool) float64 {
+	var average float64
+	count := w.count
+	if count < w.period {
+		count++
+		average = (w.average*float64(count-1) + value) / float64(count)
+	} else {
+		average = (w.average*float64(w.period-1) + value) / float64(w.period)
+	}
+	if closed {
+		w.average, w.count = average, count
+	}
+	if count < w.period {
+		return nan
+	}
+	return average
+}
+
+// RSI is the relative strength index of closes with Wilder's smoothing
+type RSI struct {
+	gains     wilder
+	losses    wilder
+	prevClose float64
+	hasPrev   bool
+}
+
+func NewRSI(period int) *RSI {
+	return &RSI{gains: wilder{period: period}, losses: wilder{period: period}}
+}
+
+func (r *RSI) Update(candle models.Candle, closed bool) []float64 {
+	if !r.hasPrev {
+		if closed {
+			r.prevClose, r.hasPrev = candle.Close, true
+		}
+		return []float64{nan}
+	}
+
+	change := candle.Close - r.prevClose
+	gain := r.gains.next(math.Max(change, 0), closed)
+	loss := r.losses.next(math.Max(-change, 0), closed)
+	if closed {
+		r.prevClose = candle.Close
+	}
+
+	switch {
+	case math.IsNaN(gain):
+		return []float64{nan}
+	case loss == 0 && gain == 0:
+		return []float64{50}
+	case loss == 0:
+		return []float64{100}
+	}
+	return []float64{100 - 100/(1+gain/loss)}
+}
+
+func (r *RSI) Reset() {
+	period := r.gains.period
+	*r = *NewRSI(period)
+}
+
+// MACD is the difference between a fast and a slow EMA of closes with an
+// EMA signal line. Values are MACD, signal and histogram.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+}
+
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+}
+
+func (m *MACD) Update(candle models.Candle, closed bool) []float64 {
+	fast := m.fast.next(candle.Close, closed)
+	slow := m.slow.next(candle.Close, closed)
+	if math.IsNaN(fast) || math.IsNaN(slow) {
+		return []float64{nan, nan, nan}
+	}
+
+	macd := fast - slow
+	signal := m.signal.next(macd, closed)
+	return []float64{macd, signal, macd - signal}
+}
+
+func (m *MACD) Reset() {
+	m.fast.Reset()
+	m.slow.Reset()
+	m.signal.Reset()
+}
+
+// ATR is the average true range with Wilder's smoothing
+type ATR struct {
+	ranges    wilder
+	prevClose float64
+	hasPrev   bool
+}
+
+func NewATR(period int) *ATR {
+	return &ATR{ranges: wilder{period: period}}
+}
+
+func (a *ATR) Update(candle models.Candle, closed bool) []float64 {
+	trueRange := candle.High - candle.Low
+	if a.hasPrev {
+		trueRange = math.Max(trueRange, math.Max(
+			math.Abs(candle.High-a.prevClose),
+			math.Abs(candle.Low-a.prevClose)))
+	}
+	if closed {
+		a.prevClose, a.hasPrev = candle.Close, true
+	}
+	return []float64{a.ranges.next(trueRange, closed)}
+}
+
+func (a *ATR) Reset() {
+	a.ranges.average, a.ranges.count = 0, 0
+	a.hasPrev = false
+}
+
+// Stochastic is the position of the close within the high-low range of the
+// last period bars (%K), with an SMA of it (%D). Values are %K and %D.
+type Stochastic struct {
+	period int
+	highs  ring
+	lows   ring
+	d      *SMA
+}
+
+func NewStochastic(period, smoothing int) *Stochastic {
+	return &Stochastic{
+		period: period,
+		highs:  newRing(period),
+		lows:   newRing(period),
+		d:      NewSMA(smoothing),
+	}
+}
+
+func (s *Stochastic) Update(candle models.Candle, closed bool) []float64 {
+	count := s.highs.count + 1
+	highest, lowest := candle.High, candle.Low
+
+	// Range over the last period bars including this one, so skip the
+	// oldest when the window is already full
+	skip := 0
+	if s.highs.full() {
+		skip = 1
+		count = s.period
+	}
+	i := 0
+	s.highs.each(func(high float64) {
+		if i >= skip {
+			highest = math.Max(highest, high)
+		}
+		i++
+	})
+	i = 0
+	s.lows.each(func(low float64) {
+		if i >= skip {
+			lowest = math.Min(lowest, low)
+		}
+		i++
+	})
+
+	if closed {
+		s.highs.push(candle.High)
+		s.lows.push(candle.Low)
+	}
+	if count < s.period {
+		return []float64{nan, nan}
+	}
+
+	k := 50.0
+	if highest > lowest {
+		k = 100 * (candle.Close - lowest) / (highest - lowest)
+	}
+	return []float64{k, s.d.next(k, closed)}
+}
+
+func (s *Stochastic) Reset() {
+	s.highs.reset()
+	s.lows.reset()
+	s.d.Reset()
+}
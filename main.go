@@ -5,10 +5,13 @@ import (
 	"log"
 	"time"
 
+	"github.com/adityanagar10/trader/alerts"
 	"github.com/adityanagar10/trader/client"
 	"github.com/adityanagar10/trader/components"
 	colors "github.com/adityanagar10/trader/constants"
+	"github.com/adityanagar10/trader/marketdata"
 	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/registry"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -27,6 +30,12 @@ func NewWindow(title string, x, y, width, height float32, contentFunc func(*mode
 	}
 }
 
+// instrumentRefreshInterval is how often the instrument registry is reloaded
+const instrumentRefreshInterval = time.Hour
+
+// defaultInstruments are offered until the registry has loaded
+var defaultInstruments = []string{"BTC-PERPETUAL", "ETH-PERPETUAL", "SOL-PERPETUAL", "XRP-PERPETUAL"}
+
 func main() {
 	rl.SetConfigFlags(rl.FlagWindowResizable)
 	rl.InitWindow(1200, 800, "Go Trader")
@@ -35,67 +44,194 @@ func main() {
 	font := rl.LoadFont("JetBrainsMono-Regular.ttf")
 	rl.SetTextureFilter(font.Texture, rl.FilterBilinear)
 
-	orderBookWindow := NewWindow("deribit btcusdt - Orderbook", 510, 85, 580, 400, components.RenderOrderBook, font)
-	orderBookWindow.IsActive = true
+	instrument := "BTC-PERPETUAL"
 
-	intrumentGraph := NewWindow("derbit btcusdt - Graph", 10, 85, 500, 500, components.RenderIntrumentGraph, font)
+	// Network goroutines publish into the store, the render loop reads from it
+	store := marketdata.NewStore()
 
-	// Windows list
-	windows := []*models.Window{
-		orderBookWindow,
-		intrumentGraph,
-	}
+	// Create Deribit client and connect
+	deribitClient := client.NewDeribitClient(instrument, store)
 
-	// Create dropdown for instrument selection
-	instrumentDropdown := components.NewDropdown(
-		10, 50, 200,
-		[]string{"BTC-PERPETUAL", "ETH-PERPETUAL", "SOL-PERPETUAL", "XRP-PERPETUAL"},
-		"Instrument",
-		font)
+	// The cached registry is used until a refresh, which happens once
+	// connected if the cache is stale and then on an interval
+	instruments := registry.NewRegistry(deribitClient, store)
+	if err := instruments.LoadCache(); err != nil {
+		log.Printf("Failed to load instrument cache: %v", err)
+	}
+	deribitClient.OnStateChange(func(state client.ConnState) {
+		if state == client.StateLive && time.Since(instruments.UpdatedAt()) > instrumentRefreshInterval {
+			instruments.RefreshNow()
+		}
+	})
+	instruments.Start(instrumentRefreshInterval)
+	defer instruments.Stop()
 
-	// Create Deribit client and connect
-	deribitClient := client.NewDeribitClient("BTC-PERPETUAL", orderBookWindow)
 	err := deribitClient.Connect()
 	if err != nil {
 		log.Printf("Failed to connect: %v", err)
 	}
 	defer deribitClient.Close()
 
-	// Set handler for instrument change
-	instrumentDropdown.SetOnChangeHandler(func(idx int) {
-		selectedInstrument := instrumentDropdown.GetSelectedOption()
-
-		// TODO: move to constants
-		var symbol string
-		switch selectedInstrument {
-		case "BTC-PERPETUAL":
-			symbol = "btcusdt"
-		case "ETH-PERPETUAL":
-			symbol = "ethusdt"
-		case "SOL-PERPETUAL":
-			symbol = "solusdt"
-		case "XRP-PERPETUAL":
-			symbol = "xrpusdt"
+	orderBook := components.NewOrderBook(store, font)
+	orderBook.SetOnDepthHandler(func(depth int) {
+		deribitClient.WatchBookDepth("orderbook", depth)
+	})
+	orderBookWindow := NewWindow("", 510, 85, 580, 400, orderBook.Render, font)
+	orderBookWindow.IsActive = true
+	orderBookWindow.Feed = string(marketdata.KindOrderBook)
+	orderBookWindow.TitleFormat = "deribit %s - Orderbook"
+
+	instrumentGraph := components.NewInstrumentGraph(deribitClient, store, font)
+	graphWindow := NewWindow("", 10, 85, 500, 500, instrumentGraph.Render, font)
+	graphWindow.TitleFormat = "deribit %s - Graph"
+
+	recentTradesWindow := NewWindow("", 510, 490, 580, 290, components.RenderRecentTrades, font)
+	recentTradesWindow.Feed = string(marketdata.KindTrades)
+	recentTradesWindow.TitleFormat = "deribit %s - Recent Trades"
+
+	depthChart := components.NewDepthChart()
+	depthWindow := NewWindow("", 10, 590, 500, 190, depthChart.Render, font)
+	depthWindow.Feed = string(marketdata.KindOrderBook)
+	depthWindow.TitleFormat = "deribit %s - Depth"
+
+	liquidityHeatmap := components.NewLiquidityHeatmap(store)
+	heatmapWindow := NewWindow("", 590, 120, 600, 400, liquidityHeatmap.Render, font)
+	heatmapWindow.Feed = string(marketdata.KindOrderBook)
+	heatmapWindow.TitleFormat = "deribit %s - Heatmap"
+
+	marketStatsWindow := NewWindow("", 620, 160, 380, 320, components.RenderMarketStats, font)
+	marketStatsWindow.Feed = string(marketdata.KindTicker)
+	marketStatsWindow.TitleFormat = "deribit %s - Market Stats"
+
+	// The watchlist follows the tickers of the rows it shows
+	watchlist := components.NewWatchlist(store, instrument)
+	watchlist.SetOnVisibleHandler(func(instruments []string) {
+		deribitClient.WatchTickers("watchlist", instruments)
+	})
+	watchlistWindow := NewWindow("Watchlist", 200, 200, 640, 260, watchlist.Render, font)
+
+	optionsChain := components.NewOptionsChain(store, instruments, "BTC")
+	optionsChain.SetOnVisibleHandler(func(options []string) {
+		deribitClient.WatchTickers("options", options)
+	})
+	optionsChainWindow := NewWindow("Options Chain", 100, 120, 1000, 500, optionsChain.Render, font)
+
+	volSmile := components.NewVolSmile(store, instruments, "BTC", font)
+	volSmile.SetOnIndexHandler(deribitClient.WatchOptionMarks)
+	volSmileWindow := NewWindow("Volatility", 150, 150, 720, 440, volSmile.Render, font)
+
+	futuresCurve := components.NewFuturesCurve(store, instruments, "BTC", instrument)
+	futuresCurve.SetOnVisibleHandler(func(futures []string) {
+		deribitClient.WatchTickers("futures", futures)
+	})
+	futuresCurveWindow := NewWindow("Futures Curve", 200, 100, 640, 480, futuresCurve.Render, font)
+
+	// Funding of the perpetuals compared for carry, with a month of history
+	fundingPerpetuals := []string{"BTC-PERPETUAL", "ETH-PERPETUAL", "SOL_USDC-PERPETUAL", "XRP_USDC-PERPETUAL"}
+	deribitClient.WatchTickers("funding", fundingPerpetuals)
+	deribitClient.FollowFundingHistory(fundingPerpetuals, 30*24*time.Hour)
+	fundingPanel := components.NewFundingPanel(store, fundingPerpetuals)
+	fundingWindow := NewWindow("Funding", 250, 140, 680, 420, fundingPanel.Render, font)
+
+	// Alerts are evaluated on the engine's goroutine as tickers come in,
+	// the client follows the tickers of every instrument with an alert
+	alertEngine := alerts.NewEngine(store)
+	if err := alertEngine.Load(); err != nil {
+		log.Printf("Failed to load alerts: %v", err)
+	}
+	alertEngine.SetOnInstrumentsHandler(func(instruments []string) {
+		deribitClient.WatchTickers("alerts", instruments)
+	})
+	// The connection is lost when it drops, not while it first connects
+	connected := func(state client.ConnState) bool {
+		return state != client.StateReconnecting && state != client.StateDown
+	}
+	deribitClient.OnStateChange(func(state client.ConnState) {
+		alertEngine.SetConnected(connected(state))
+	})
+	alertEngine.SetConnected(connected(deribitClient.State()))
+	alertEngine.Start()
+	defer alertEngine.Stop()
+	alertsPanel := components.NewAlertsPanel(alertEngine, instrument, font)
+	alertsWindow := NewWindow("Alerts", 180, 180, 760, 380, alertsPanel.Render, font)
+	toasts := components.NewToasts(font)
+
+	// Windows list
+	windows := []*models.Window{
+		orderBookWindow,
+		graphWindow,
+		recentTradesWindow,
+		depthWindow,
+		heatmapWindow,
+		marketStatsWindow,
+		watchlistWindow,
+		optionsChainWindow,
+		volSmileWindow,
+		futuresCurveWindow,
+		fundingWindow,
+		alertsWindow,
+	}
+
+	// Windows with a title format follow the selected instrument
+	for _, win := range windows {
+		if win.TitleFormat != "" {
+			win.SetInstrument(instrument, instruments.Symbol(instrument))
 		}
+	}
 
-		orderBookWindow.Title = fmt.Sprintf("deribit %s - Orderbook", symbol)
+	// Create dropdown for instrument selection
+	instrumentPicker := components.NewInstrumentPicker(10, 50, 300, instruments, instrument, defaultInstruments, font)
+	instrumentsVersion := instruments.Version()
 
-		// Connect to appropriate WebSocket for selected instrument
-		if deribitClient != nil {
-			deribitClient.Instrument = selectedInstrument
-			// Clear data while loading
-			orderBookWindow.Data = nil
+	// Set handler for instrument change
+	instrumentPicker.SetOnSelectHandler(func(selectedInstrument string) {
+		// Resubscribe in the background, late messages for the old
+		// instrument are dropped by the client
+		deribitClient.SwitchInstrument(selectedInstrument)
+
+		// Rebind every window in the same frame so titles and data always match
+		symbol := instruments.Symbol(selectedInstrument)
+		for _, win := range windows {
+			if win.TitleFormat != "" {
+				win.SetInstrument(selectedInstrument, symbol)
+			}
 		}
+		watchlist.Focus(selectedInstrument)
+		futuresCurve.Focus(selectedInstrument)
+		alertsPanel.Focus(selectedInstrument)
 		fmt.Printf("Switched to instrument: %s\n", selectedInstrument)
 	})
 
+	// Clicking a watchlist or futures curve row switches like picking it
+	watchlist.SetOnSelectHandler(instrumentPicker.Select)
+	futuresCurve.SetOnSelectHandler(instrumentPicker.Select)
+
 	// Main loop
 	for !rl.WindowShouldClose() {
-		// Update
+		// Update; the picker goes first and captures the input while it is
+		// open, and in the frame a click closes it, so the windows under
+		// it never act on its clicks and keys
+		pickerWasOpen := instrumentPicker.IsOpen
+		instrumentPicker.Update()
+		models.CaptureInput(pickerWasOpen || instrumentPicker.IsOpen)
+		models.TrackMouse(windows)
 		for _, win := range windows {
+			store.Sync(win)
 			win.Update(windows)
 		}
-		instrumentDropdown.Update()
+		// The window clicked last is drawn over the others
+		models.BringToFront(windows)
+
+		// Symbols may change when the registry reloads
+		if version := instruments.Version(); version != instrumentsVersion {
+			instrumentsVersion = version
+			symbol := instruments.Symbol(instrumentPicker.Selected)
+			for _, win := range windows {
+				if win.TitleFormat != "" {
+					win.Title = fmt.Sprintf(win.TitleFormat, symbol)
+				}
+			}
+		}
 
 		// Draw
 		rl.BeginDrawing()
@@ -103,16 +239,28 @@ func main() {
 
 		rl.DrawText("Go Trader", 10, 10, 20, colors.ColorText)
 
-		// Draw instrument dropdown
-		instrumentDropdown.Draw()
-
 		// Draw all windows
 		for _, win := range windows {
 			win.Draw()
 		}
 
+		// Draw instrument picker over the windows
+		instrumentPicker.Draw()
+
+		// Fired alerts pop up over everything
+		toasts.Add(alertEngine.TakeNotifications()...)
+		toasts.Draw()
+
+		// Connection state from the client supervisor
 		statusY := rl.GetScreenHeight() - 20
-		rl.DrawText("Connected to Deribit", 10, int32(statusY), 16, colors.ColorSubtext)
+		statusColor := colors.ColorSubtext
+		switch deribitClient.State() {
+		case client.StateLive:
+			statusColor = colors.ColorGreen
+		case client.StateDown:
+			statusColor = colors.ColorRed
+		}
+		rl.DrawText(fmt.Sprintf("Deribit: %s", deribitClient.State()), 10, int32(statusY), 16, statusColor)
 
 		// Draw timestamp on the right
 		timeText := time.Now().Format("15:04:05")
@@ -122,6 +270,7 @@ func main() {
 		rl.EndDrawing()
 	}
 
+	liquidityHeatmap.Close()
 	rl.UnloadFont(font)
 	rl.CloseWindow()
 }
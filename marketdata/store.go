@@ -0,0 +1,194 @@
+package marketdata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adityanagar10/trader/analytics"
+	"github.com/adityanagar10/trader/models"
+)
+
+// Kind identifies a type of market data published for an instrument
+type Kind string
+
+const (
+	KindOrderBook Kind = "orderbook"
+	KindTrades    Kind = "trades"
+	KindTicker    Kind = "ticker"
+	// KindOptionMarks are the marks of every option on a price index,
+	// stored under the index name rather than an instrument
+	KindOptionMarks Kind = "optionmarks"
+	// KindFundingHistory is a perpetual's hourly funding, oldest first
+	KindFundingHistory Kind = "fundinghistory"
+	// KindInstrument is the instrument's specification
+	KindInstrument Kind = "instrument"
+	// KindBookMetrics are the analytics of the latest order book
+	KindBookMetrics Kind = "bookmetrics"
+)
+
+// BookDepthKind is an instrument's book cut by Deribit to depth levels per
+// side, kept apart from the full book of KindOrderBook
+func BookDepthKind(depth int) Kind {
+	return Kind(fmt.Sprintf("book%d", depth))
+}
+
+// Snapshot is one published value. Values are never modified after they
+// are published, so readers can hold on to them without locking.
+type Snapshot struct {
+	Value     interface{}
+	Version   uint64
+	UpdatedAt time.Time
+}
+
+type key struct {
+	instrument string
+	kind       Kind
+}
+
+// Store is the hand-off point between network goroutines, which publish
+// snapshots, and the render loop, which reads them once per frame.
+// Versions increase across the whole store, so a reader that remembers the
+// last version it saw can tell when anything it shows has changed.
+type Store struct {
+	mu        sync.RWMutex
+	entries   map[key]Snapshot
+	version   uint64
+	listeners []func(instrument string, kind Kind, snapshot Snapshot)
+}
+
+func NewStore() *Store {
+	return &Store{
+		entries: make(map[key]Snapshot),
+	}
+}
+
+// OnPublish registers a listener for every published snapshot. Listeners
+// run on the publishing goroutine and must not block.
+func (s *Store) OnPublish(listener func(instrument string, kind Kind, snapshot Snapshot)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Publish replaces the snapshot for an instrument and kind and returns its version
+func (s *Store) Publish(instrument string, kind Kind, value interface{}) uint64 {
+	s.mu.Lock()
+	s.version++
+	snapshot := Snapshot{
+		Value:     value,
+		Version:   s.version,
+		UpdatedAt: time.Now(),
+	}
+	s.entries[key{instrument, kind}] = snapshot
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(instrument, kind, snapshot)
+	}
+	return snapshot.Version
+}
+
+// Get returns the latest snapshot for an instrument and kind
+func (s *Store) Get(instrument string, kind Kind) (Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.entries[key{instrument, kind}]
+	return snapshot, ok
+}
+
+// OrderBook returns the latest order book of an instrument, nil if none
+func (s *Store) OrderBook(instrument string) (*models.OrderBookResult, uint64) {
+	snapshot, ok := s.Get(instrument, KindOrderBook)
+	if !ok {
+		return nil, 0
+	}
+	orderBook, _ := snapshot.Value.(*models.OrderBookResult)
+	return orderBook, snapshot.Version
+}
+
+// Trades returns the recent trades of an instrument, newest first
+func (s *Store) Trades(instrument string) ([]models.Trade, uint64) {
+	snapshot, ok := s.Get(instrument, KindTrades)
+	if !ok {
+		return nil, 0
+	}
+	trades, _ := snapshot.Value.([]models.Trade)
+	return trades, snapshot.Version
+}
+
+// Ticker returns the latest ticker of an instrument, nil if none
+func (s *Store) Ticker(instrument string) (*models.Ticker, uint64) {
+	snapshot, ok := s.Get(instrument, KindTicker)
+	if !ok {
+		return nil, 0
+	}
+	ticker, _ := snapshot.Value.(*models.Ticker)
+	return ticker, snapshot.Version
+}
+
+// OptionMarks returns the latest marks of the options on a price index
+func (s *Store) OptionMarks(index string) ([]models.OptionMark, uint64) {
+	snapshot, ok := s.Get(index, KindOptionMarks)
+	if !ok {
+		return nil, 0
+	}
+	marks, _ := snapshot.Value.([]models.OptionMark)
+	return marks, snapshot.Version
+}
+
+// FundingHistory returns the funding history of a perpetual, oldest first
+func (s *Store) FundingHistory(instrument string) ([]models.FundingRate, uint64) {
+	snapshot, ok := s.Get(instrument, KindFundingHistory)
+	if !ok {
+		return nil, 0
+	}
+	history, _ := snapshot.Value.([]models.FundingRate)
+	return history, snapshot.Version
+}
+
+// BookMetrics returns the analytics of an instrument's latest order book,
+// nil if none
+func (s *Store) BookMetrics(instrument string) (*analytics.Metrics, uint64) {
+	snapshot, ok := s.Get(instrument, KindBookMetrics)
+	if !ok {
+		return nil, 0
+	}
+	metrics, _ := snapshot.Value.(*analytics.Metrics)
+	return metrics, snapshot.Version
+}
+
+// Instrument returns the specification of an instrument, nil until loaded
+func (s *Store) Instrument(instrument string) *models.Instrument {
+	snapshot, ok := s.Get(instrument, KindInstrument)
+	if !ok {
+		return nil
+	}
+	spec, _ := snapshot.Value.(*models.Instrument)
+	return spec
+}
+
+// Sync loads the latest snapshot of the window's feed into its Data, and
+// the instrument's specification into Spec, and reports whether the data
+// changed. Call it from the render loop before drawing.
+func (s *Store) Sync(w *models.Window) bool {
+	w.Spec = s.Instrument(w.Instrument)
+	if w.Feed == "" {
+		return false
+	}
+
+	snapshot, ok := s.Get(w.Instrument, Kind(w.Feed))
+	if snapshot.Version == w.DataVersion {
+		return false
+	}
+
+	if ok {
+		w.Data = snapshot.Value
+	} else {
+		w.Data = nil
+	}
+	w.DataVersion = snapshot.Version
+	return true
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Candle is one OHLCV bar starting at Time. Volume is in the base
+// currency, as Deribit's chart data reports it.
+type Candle struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+type ChartDataParams struct {
+	InstrumentName string `json:"instrument_name"`
+	StartTimestamp int64  `json:"start_timestamp"`
+	EndTimestamp   int64  `json:"end_timestamp"`
+	Resolution     string `json:"resolution"`
+}
+
+// ChartData is the result of public/get_tradingview_chart_data, one entry
+// per bar in each slice
+type ChartData struct {
+	Status string    `json:"status"`
+	Ticks  []int64   `json:"ticks"`
+	Open   []float64 `json:"open"`
+	High   []float64 `json:"high"`
+	Low    []float64 `json:"low"`
+	Close  []float64 `json:"close"`
+	Volume []float64 `json:"volume"`
+	Cost   []float64 `json:"cost"`
+}
+
+// Candles converts the column-oriented chart data into candles
+func (d *ChartData) Candles() []Candle {
+	n := len(d.Ticks)
+	for _, column := range [][]float64{d.Open, d.High, d.Low, d.Close, d.Volume} {
+		n = min(n, len(column))
+	}
+
+	candles := make([]Candle, n)
+	for i := range candles {
+		candles[i] = Candle{
+			Time:   time.UnixMilli(d.Ticks[i]),
+			Open:   d.Open[i],
+			High:   d.High[i],
+			Low:    d.Low[i],
+			Close:  d.Close[i],
+			Volume: d.Volume[i],
+		}
+	}
+	return candles
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type DeribitRequest struct {
 	JsonRPC string      `json:"jsonrpc"`
 	Method  string      `json:"method"`
@@ -7,18 +12,50 @@ type DeribitRequest struct {
 	ID      int         `json:"id"`
 }
 
+// DeribitResponse covers both replies to our requests and the
+// subscription notifications pushed by the server
 type DeribitResponse struct {
-	JsonRPC string           `json:"jsonrpc"`
-	ID      int              `json:"id"`
-	Result  *OrderBookResult `json:"result,omitempty"`
-	Error   *DeribitError    `json:"error,omitempty"`
-	UsIn    int64            `json:"usIn,omitempty"`
-	UsOut   int64            `json:"usOut,omitempty"`
-	UsDiff  int              `json:"usDiff,omitempty"`
-	Testnet bool             `json:"testnet,omitempty"`
+	JsonRPC string              `json:"jsonrpc"`
+	ID      int                 `json:"id"`
+	Method  string              `json:"method,omitempty"`
+	Params  *NotificationParams `json:"params,omitempty"`
+	Result  json.RawMessage     `json:"result,omitempty"`
+	Error   *DeribitError       `json:"error,omitempty"`
+	UsIn    int64               `json:"usIn,omitempty"`
+	UsOut   int64               `json:"usOut,omitempty"`
+	UsDiff  int                 `json:"usDiff,omitempty"`
+	Testnet bool                `json:"testnet,omitempty"`
 }
 
+// DeribitError is a JSON-RPC error returned by the API
 type DeribitError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *DeribitError) Error() string {
+	return fmt.Sprintf("deribit error %d: %s", e.Code, e.Message)
+}
+
+type SubscriptionParams struct {
+	Channels []string `json:"channels"`
+}
+
+type HeartbeatParams struct {
+	Interval int `json:"interval"`
+}
+
+type AuthParams struct {
+	GrantType    string `json:"grant_type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// NotificationParams is the payload of a "subscription" or "heartbeat"
+// notification. Heartbeats only carry Type.
+type NotificationParams struct {
+	Channel string          `json:"channel,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Type    string          `json:"type,omitempty"`
 }
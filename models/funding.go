@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type FundingRateHistoryParams struct {
+	InstrumentName string `json:"instrument_name"`
+	StartTimestamp int64  `json:"start_timestamp"`
+	EndTimestamp   int64  `json:"end_timestamp"`
+}
+
+// FundingRate is an hourly entry of public/get_funding_rate_history.
+// The interests are fractions of the position's value.
+type FundingRate struct {
+	Timestamp      int64   `json:"timestamp"`
+	IndexPrice     float64 `json:"index_price"`
+	PrevIndexPrice float64 `json:"prev_index_price"`
+	// Interest1h is the funding of the hour, Interest8h of the 8 hours
+	// up to Timestamp
+	Interest1h float64 `json:"interest_1h"`
+	Interest8h float64 `json:"interest_8h"`
+}
+
+func (f FundingRate) Time() time.Time {
+	return time.UnixMilli(f.Timestamp)
+}
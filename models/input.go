@@ -0,0 +1,108 @@
+package models
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Windows handle their own clicks and keys while they draw. An overlay
+// drawn over them, like the instrument picker, captures the input for the
+// frames it is open, so a click or key meant for it is not also acted on
+// by whatever sits under it. Where windows overlap the mouse belongs to the
+// topmost one under it only. Windows and their components read the mouse
+// and keyboard through these functions rather than raylib's, which see
+// nothing while the input is captured, and no mouse while a window other
+// than the one under it draws.
+
+// inputCaptured is set while an overlay holds the input
+var inputCaptured bool
+
+// mouseWindow is the window the mouse belongs to this frame, nil when it
+// is over none of them or the input is captured
+var mouseWindow *Window
+
+// drawing is the window whose content is being drawn
+var drawing *Window
+
+// CaptureInput keeps the mouse and keyboard from the windows until it is
+// called again with false. Call it once a frame before the windows are
+// updated and drawn.
+func CaptureInput(captured bool) {
+	inputCaptured = captured
+}
+
+// TrackMouse finds the window the mouse belongs to this frame: the one
+// being dragged or resized, otherwise the last of windows, which are in
+// drawing order, under the mouse or its resize handles. Call it once a
+// frame after CaptureInput and before the windows are updated and drawn.
+func TrackMouse(windows []*Window) {
+	mouseWindow = nil
+	defer func() {
+		// Windows set the cursor only while they have the mouse
+		if mouseWindow == nil {
+			rl.SetMouseCursor(rl.MouseCursorDefault)
+		}
+	}()
+	if inputCaptured {
+		return
+	}
+	for _, w := range windows {
+		if w.IsDragging || w.IsResizing {
+			mouseWindow = w
+			return
+		}
+	}
+	mousePos := rl.GetMousePosition()
+	for i := len(windows) - 1; i >= 0; i-- {
+		rect := windows[i].Rect
+		rect.Width += windows[i].ResizeHandleSize / 2
+		rect.Height += windows[i].ResizeHandleSize / 2
+		if rl.CheckCollisionPointRec(mousePos, rect) {
+			mouseWindow = windows[i]
+			return
+		}
+	}
+}
+
+// HasMouse tells whether the mouse belongs to w this frame
+func (w *Window) HasMouse() bool {
+	return w == mouseWindow
+}
+
+// mouseAllowed tells whether the code running now may see the mouse
+func mouseAllowed() bool {
+	return !inputCaptured && (drawing == nil || drawing == mouseWindow)
+}
+
+func IsMouseButtonPressed(button rl.MouseButton) bool {
+	return mouseAllowed() && rl.IsMouseButtonPressed(button)
+}
+
+func IsMouseButtonDown(button rl.MouseButton) bool {
+	return mouseAllowed() && rl.IsMouseButtonDown(button)
+}
+
+func GetMouseWheelMove() float32 {
+	if !mouseAllowed() {
+		return 0
+	}
+	return rl.GetMouseWheelMove()
+}
+
+func IsKeyPressed(key int32) bool {
+	return !inputCaptured && rl.IsKeyPressed(key)
+}
+
+func IsKeyPressedRepeat(key int32) bool {
+	return !inputCaptured && rl.IsKeyPressedRepeat(key)
+}
+
+func IsKeyDown(key int32) bool {
+	return !inputCaptured && rl.IsKeyDown(key)
+}
+
+// GetCharPressed returns the next character typed, 0 when there is none
+// or the input is captured
+func GetCharPressed() int32 {
+	if inputCaptured {
+		return 0
+	}
+	return rl.GetCharPressed()
+}
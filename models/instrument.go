@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type InstrumentParams struct {
+	InstrumentName string `json:"instrument_name"`
+}
+
+type InstrumentsParams struct {
+	Currency string `json:"currency"`
+	Kind     string `json:"kind,omitempty"`
+	Expired  bool   `json:"expired"`
+}
+
+// Currency is an entry of public/get_currencies
+type Currency struct {
+	Currency     string `json:"currency"`
+	CurrencyLong string `json:"currency_long"`
+}
+
+// Instrument is the contract specification returned by public/get_instrument
+type Instrument struct {
+	InstrumentName      string  `json:"instrument_name"`
+	Kind                string  `json:"kind"`
+	BaseCurrency        string  `json:"base_currency"`
+	QuoteCurrency       string  `json:"quote_currency"`
+	SettlementCurrency  string  `json:"settlement_currency"`
+	CounterCurrency     string  `json:"counter_currency"`
+	SettlementPeriod    string  `json:"settlement_period"`
+	InstrumentType      string  `json:"instrument_type"`
+	PriceIndex          string  `json:"price_index"`
+	TickSize            float64 `json:"tick_size"`
+	ContractSize        float64 `json:"contract_size"`
+	MinTradeAmount      float64 `json:"min_trade_amount"`
+	CreationTimestamp   int64   `json:"creation_timestamp"`
+	ExpirationTimestamp int64   `json:"expiration_timestamp"`
+	IsActive            bool    `json:"is_active"`
+	OptionType          string  `json:"option_type,omitempty"`
+	Strike              float64 `json:"strike,omitempty"`
+}
+
+// Perpetual reports whether the instrument is a perpetual future
+func (i *Instrument) Perpetual() bool {
+	return i.Kind == "future" && i.SettlementPeriod == "perpetual"
+}
+
+// Expiry is when the instrument expires, zero for perpetuals and spot
+func (i *Instrument) Expiry() time.Time {
+	if i.ExpirationTimestamp <= 0 || i.Perpetual() || i.Kind == "spot" {
+		return time.Time{}
+	}
+	return time.UnixMilli(i.ExpirationTimestamp)
+}
+
+// Index is the price index the instrument settles against, as in btc_usd
+func (i *Instrument) Index() string {
+	if i.PriceIndex != "" {
+		return i.PriceIndex
+	}
+	// Older instrument caches predate the field
+	return strings.ToLower(i.BaseCurrency + "_" + i.CounterCurrency)
+}
+
+// Formatting methods work on a nil *Instrument, falling back to two price
+// and four amount decimals until the specification is loaded.
+
+// Inverse reports whether amounts are in USD rather than the base currency
+func (i *Instrument) Inverse() bool {
+	return i != nil && i.InstrumentType == "reversed"
+}
+
+// PriceDecimals is the number of decimals in the tick size
+func (i *Instrument) PriceDecimals() int {
+	if i == nil || i.TickSize <= 0 {
+		return 2
+	}
+	return stepDecimals(i.TickSize)
+}
+
+// AmountDecimals is the number of decimals in the amount step
+func (i *Instrument) AmountDecimals() int {
+	if i == nil || i.MinTradeAmount <= 0 {
+		return 4
+	}
+	return stepDecimals(i.MinTradeAmount)
+}
+
+func (i *Instrument) FormatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', i.PriceDecimals(), 64)
+}
+
+func (i *Instrument) FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', i.AmountDecimals(), 64)
+}
+
+// Notional is the USD value of amount at price
+func (i *Instrument) Notional(amount, price float64) float64 {
+	if i.Inverse() {
+		return amount
+	}
+	return amount * price
+}
+
+// BaseAmount converts amount at price to the base currency, as in BTC
+func (i *Instrument) BaseAmount(amount, price float64) float64 {
+	if i.Inverse() && price > 0 {
+		return amount / price
+	}
+	return amount
+}
+
+// FormatNotional formats a USD value compactly, as in $1.25M
+func FormatNotional(value float64) string {
+	abs := math.Abs(value)
+	switch {
+	case abs >= 1e9:
+		return fmt.Sprintf("$%.2fB", value/1e9)
+	case abs >= 1e6:
+		return fmt.Sprintf("$%.2fM", value/1e6)
+	case abs >= 1e3:
+		return fmt.Sprintf("$%.1fK", value/1e3)
+	}
+	return fmt.Sprintf("$%.0f", value)
+}
+
+// stepDecimals counts the decimals needed to show multiples of step
+func stepDecimals(step float64) int {
+	text := strconv.FormatFloat(step, 'f', -1, 64)
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		return min(len(text)-dot-1, 8)
+	}
+	return 0
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type OrderBookParams struct {
 	InstrumentName string `json:"instrument_name"`
 }
@@ -36,4 +41,46 @@ type OrderBookResult struct {
 	BestBidAmount    float64        `json:"best_bid_amount"`
 	CurrentFunding   float64        `json:"current_funding"`
 	Funding8h        float64        `json:"funding_8h"`
+
+	// Resyncing is set while the client waits for a fresh snapshot after
+	// a sequence gap, Resyncs counts how often that has happened
+	Resyncing bool  `json:"-"`
+	Resyncs   int64 `json:"-"`
+}
+
+// BookUpdate is the data of a book.{instrument}.{interval} notification.
+// The first message after subscribing is a "snapshot", every following
+// one is a "change" relative to PrevChangeID.
+type BookUpdate struct {
+	Type           string            `json:"type"`
+	Timestamp      int64             `json:"timestamp"`
+	InstrumentName string            `json:"instrument_name"`
+	ChangeID       int64             `json:"change_id"`
+	PrevChangeID   int64             `json:"prev_change_id"`
+	Bids           []BookLevelUpdate `json:"bids"`
+	Asks           []BookLevelUpdate `json:"asks"`
+}
+
+// BookLevelUpdate is a single ["new"|"change"|"delete", price, amount] entry
+type BookLevelUpdate struct {
+	Action string
+	Price  float64
+	Amount float64
+}
+
+func (u *BookLevelUpdate) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("book level update has %d fields, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &u.Action); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &u.Price); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &u.Amount)
 }
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Ticker is the data of a ticker.{instrument}.{interval} notification.
+// It carries the same market summary as the order book, without levels.
+type Ticker struct {
+	Timestamp        int64          `json:"timestamp"`
+	State            string         `json:"state"`
+	Stats            OrderBookStats `json:"stats"`
+	InstrumentName   string         `json:"instrument_name"`
+	LastPrice        float64        `json:"last_price"`
+	MarkPrice        float64        `json:"mark_price"`
+	IndexPrice       float64        `json:"index_price"`
+	SettlementPrice  float64        `json:"settlement_price"`
+	EstDeliveryPrice float64        `json:"estimated_delivery_price"`
+	MinPrice         float64        `json:"min_price"`
+	MaxPrice         float64        `json:"max_price"`
+	OpenInterest     float64        `json:"open_interest"`
+	InterestValue    float64        `json:"interest_value"`
+	BestBidPrice     float64        `json:"best_bid_price"`
+	BestBidAmount    float64        `json:"best_bid_amount"`
+	BestAskPrice     float64        `json:"best_ask_price"`
+	BestAskAmount    float64        `json:"best_ask_amount"`
+	CurrentFunding   float64        `json:"current_funding"`
+	Funding8h        float64        `json:"funding_8h"`
+
+	// Options only: implied volatilities in percent, the greeks and the
+	// price of what the option settles against
+	MarkIV          float64 `json:"mark_iv"`
+	BidIV           float64 `json:"bid_iv"`
+	AskIV           float64 `json:"ask_iv"`
+	Greeks          *Greeks `json:"greeks,omitempty"`
+	UnderlyingPrice float64 `json:"underlying_price"`
+	UnderlyingIndex string  `json:"underlying_index"`
+	InterestRate    float64 `json:"interest_rate"`
+}
+
+// OptionMark is an entry of a markprice.options.{index} notification,
+// which lists the mark of every option on the index
+type OptionMark struct {
+	InstrumentName string  `json:"instrument_name"`
+	MarkPrice      float64 `json:"mark_price"`
+	// IV is the mark implied volatility as a fraction, unlike the percent
+	// of the ticker
+	IV        float64 `json:"iv"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// Greeks are Deribit's option sensitivities: vega per 1% of volatility and
+// theta per day, both in USD
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Vega  float64 `json:"vega"`
+	Theta float64 `json:"theta"`
+	Rho   float64 `json:"rho"`
+}
+
+// fundingPeriodsPerYear is the number of 8 hour funding periods in a year
+const fundingPeriodsPerYear = 3 * 365
+
+// Time is when Deribit produced the ticker
+func (t *Ticker) Time() time.Time {
+	return time.UnixMilli(t.Timestamp)
+}
+
+// Basis is the mark price's premium over the index
+func (t *Ticker) Basis() float64 {
+	return t.MarkPrice - t.IndexPrice
+}
+
+// BasisPct is Basis as a percentage of the index
+func (t *Ticker) BasisPct() float64 {
+	if t.IndexPrice == 0 {
+		return 0
+	}
+	return t.Basis() / t.IndexPrice * 100
+}
+
+// AnnualizedBasisPct is BasisPct scaled to a year, for futures that
+// expire at expiry. It is zero for expired or perpetual instruments.
+func (t *Ticker) AnnualizedBasisPct(expiry time.Time) float64 {
+	remaining := time.Until(expiry)
+	if expiry.IsZero() || remaining <= 0 {
+		return 0
+	}
+	return t.BasisPct() * float64(365*24*time.Hour) / float64(remaining)
+}
+
+// AnnualizedFundingPct is the 8 hour funding rate paid for a year, in percent
+func (t *Ticker) AnnualizedFundingPct() float64 {
+	return t.Funding8h * fundingPeriodsPerYear * 100
+}
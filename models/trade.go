@@ -1,8 +1,60 @@
 package models
 
+import "time"
+
+// Tick directions reported by Deribit for each trade
+const (
+	TickPlus      = 0
+	TickZeroPlus  = 1
+	TickMinus     = 2
+	TickZeroMinus = 3
+)
+
 type Trade struct {
-	Price     float64
-	Amount    float64
-	Direction string
-	Timestamp string
+	InstrumentName string
+	TradeID        string
+	TradeSeq       int64
+	Price          float64
+	Amount         float64
+	Direction      string
+	Timestamp      time.Time
+	// Liquidation is empty for regular trades, otherwise "M" (maker side
+	// liquidated), "T" (taker side) or "MT" (both)
+	Liquidation   string
+	TickDirection int
+}
+
+// Uptick reports whether the trade printed on a plus or zero-plus tick
+func (t Trade) Uptick() bool {
+	return t.TickDirection == TickPlus || t.TickDirection == TickZeroPlus
+}
+
+// DeribitTrade is a trade as sent on the trades.{instrument}.{interval} channel
+type DeribitTrade struct {
+	TradeSeq       int64   `json:"trade_seq"`
+	TradeID        string  `json:"trade_id"`
+	Timestamp      int64   `json:"timestamp"`
+	TickDirection  int     `json:"tick_direction"`
+	Price          float64 `json:"price"`
+	MarkPrice      float64 `json:"mark_price"`
+	IndexPrice     float64 `json:"index_price"`
+	InstrumentName string  `json:"instrument_name"`
+	Direction      string  `json:"direction"`
+	Amount         float64 `json:"amount"`
+	Liquidation    string  `json:"liquidation,omitempty"`
+}
+
+// Trade converts the wire format into a Trade
+func (t *DeribitTrade) Trade() Trade {
+	return Trade{
+		InstrumentName: t.InstrumentName,
+		TradeID:        t.TradeID,
+		TradeSeq:       t.TradeSeq,
+		Price:          t.Price,
+		Amount:         t.Amount,
+		Direction:      t.Direction,
+		Timestamp:      time.UnixMilli(t.Timestamp),
+		Liquidation:    t.Liquidation,
+		TickDirection:  t.TickDirection,
+	}
 }
@@ -1,10 +1,15 @@
 package models
 
 import (
+	"fmt"
+
 	colors "github.com/adityanagar10/trader/constants"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+// headerHeight is the height of the title bar above the content
+const headerHeight = float32(25)
+
 // Window struct for UI
 type Window struct {
 	Title            string
@@ -22,6 +27,30 @@ type Window struct {
 	ResizeHandleSize float32
 	Padding          float32
 	Font             rl.Font
+
+	// Instrument and Feed bind the window to a market data feed, the render
+	// loop copies its latest snapshot into Data (at DataVersion) every frame
+	Instrument  string
+	Feed        string
+	DataVersion uint64
+	// TitleFormat builds the title from the instrument's symbol
+	TitleFormat string
+	// Spec is the instrument's specification, nil until it is loaded.
+	// Components format prices and amounts with it.
+	Spec *Instrument
+}
+
+// SetInstrument rebinds the window to another instrument, dropping the data
+// of the previous one so nothing stale is drawn under the new title
+func (w *Window) SetInstrument(instrument, symbol string) {
+	w.Instrument = instrument
+	if w.TitleFormat != "" {
+		w.Title = fmt.Sprintf(w.TitleFormat, symbol)
+	}
+	w.Data = nil
+	w.DataVersion = 0
+	w.Spec = nil
+	w.ScrollPosition = 0
 }
 
 func (w *Window) Update(windows []*Window) {
@@ -58,7 +87,7 @@ func (w *Window) Update(windows []*Window) {
 	}
 
 	// Check if clicked on this window to make it active
-	if rl.CheckCollisionPointRec(mousePos, w.Rect) && rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+	if w.HasMouse() && IsMouseButtonPressed(rl.MouseLeftButton) {
 		// Make this window active and all others inactive
 		for _, win := range windows {
 			win.IsActive = false
@@ -68,7 +97,7 @@ func (w *Window) Update(windows []*Window) {
 
 	// Handle resizing
 	if w.IsResizing {
-		if rl.IsMouseButtonDown(rl.MouseLeftButton) {
+		if IsMouseButtonDown(rl.MouseLeftButton) {
 			switch w.ResizeDir {
 			case 1: // Bottom-right corner
 				w.Rect.Width = mousePos.X - w.Rect.X
@@ -90,11 +119,11 @@ func (w *Window) Update(windows []*Window) {
 			w.IsResizing = false
 			w.ResizeDir = 0
 		}
-	} else if !w.IsDragging {
+	} else if !w.IsDragging && w.HasMouse() {
 		// Check if mouse is over resize handles
 		if rl.CheckCollisionPointRec(mousePos, bottomRightRect) {
 			rl.SetMouseCursor(rl.MouseCursorResizeNWSE)
-			if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+			if IsMouseButtonPressed(rl.MouseLeftButton) {
 				w.IsResizing = true
 				w.ResizeDir = 1
 
@@ -106,7 +135,7 @@ func (w *Window) Update(windows []*Window) {
 			}
 		} else if rl.CheckCollisionPointRec(mousePos, rightEdgeRect) {
 			rl.SetMouseCursor(rl.MouseCursorResizeEW)
-			if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+			if IsMouseButtonPressed(rl.MouseLeftButton) {
 				w.IsResizing = true
 				w.ResizeDir = 2
 
@@ -118,7 +147,7 @@ func (w *Window) Update(windows []*Window) {
 			}
 		} else if rl.CheckCollisionPointRec(mousePos, bottomEdgeRect) {
 			rl.SetMouseCursor(rl.MouseCursorResizeNS)
-			if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+			if IsMouseButtonPressed(rl.MouseLeftButton) {
 				w.IsResizing = true
 				w.ResizeDir = 3
 
@@ -134,8 +163,8 @@ func (w *Window) Update(windows []*Window) {
 	}
 
 	// Dragging logic
-	if rl.CheckCollisionPointRec(mousePos, headerRect) {
-		if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+	if w.HasMouse() && rl.CheckCollisionPointRec(mousePos, headerRect) {
+		if IsMouseButtonPressed(rl.MouseLeftButton) {
 			w.IsDragging = true
 			w.DragOffset = rl.Vector2{
 				X: mousePos.X - w.Rect.X,
@@ -150,7 +179,7 @@ func (w *Window) Update(windows []*Window) {
 	}
 
 	if w.IsDragging {
-		if rl.IsMouseButtonDown(rl.MouseLeftButton) {
+		if IsMouseButtonDown(rl.MouseLeftButton) {
 			w.Rect.X = mousePos.X - w.DragOffset.X
 			w.Rect.Y = mousePos.Y - w.DragOffset.Y
 		} else {
@@ -159,8 +188,8 @@ func (w *Window) Update(windows []*Window) {
 	}
 
 	// Handle scrolling
-	if rl.CheckCollisionPointRec(mousePos, w.Rect) {
-		wheel := rl.GetMouseWheelMove()
+	if w.HasMouse() {
+		wheel := GetMouseWheelMove()
 		if wheel != 0 {
 			w.ScrollPosition -= wheel * 20
 
@@ -216,8 +245,6 @@ func (w *Window) Draw() {
 		borderColor)
 
 	// Draw minimal header - just a line with text
-	headerHeight := float32(25)
-
 	// Draw title text with monospaced font at proper scale
 	rl.DrawTextEx(
 		w.Font,
@@ -238,13 +265,10 @@ func (w *Window) Draw() {
 
 	// Draw content with scissor mode to keep it within bounds
 	if w.Content != nil {
-		rl.BeginScissorMode(
-			int32(w.Rect.X),
-			int32(w.Rect.Y+headerHeight),
-			int32(w.Rect.Width),
-			int32(w.Rect.Height-headerHeight),
-		)
+		w.ClipContent()
+		drawing = w
 		w.Content(w)
+		drawing = nil
 		rl.EndScissorMode()
 	}
 
@@ -259,3 +283,28 @@ func (w *Window) Draw() {
 		)
 	}
 }
+
+// BringToFront moves the active window to the end of windows, which are in
+// drawing order, so it is drawn over the others and gets the mouse where
+// they overlap
+func BringToFront(windows []*Window) {
+	for i, w := range windows {
+		if w.IsActive {
+			copy(windows[i:], windows[i+1:])
+			windows[len(windows)-1] = w
+			return
+		}
+	}
+}
+
+// ClipContent clips drawing to the content area below the header, as Draw
+// does around Content. Components that clip to a region of their own call
+// it to restore the window's clipping.
+func (w *Window) ClipContent() {
+	rl.BeginScissorMode(
+		int32(w.Rect.X),
+		int32(w.Rect.Y+headerHeight),
+		int32(w.Rect.Width),
+		int32(w.Rect.Height-headerHeight),
+	)
+}
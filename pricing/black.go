@@ -0,0 +1,122 @@
+// Package pricing values European options on futures with the Black-76
+// model and solves for implied volatility, for Deribit's inverse (coin
+// settled) and linear (USDC settled) options.
+package pricing
+
+import "math"
+
+// daysPerYear converts times to expiry; Deribit counts calendar days
+const daysPerYear = 365
+
+// Params describe an option and its market. Prices are in the quote
+// currency (USD) per unit of the underlying.
+type Params struct {
+	Call    bool
+	Forward float64
+	Strike  float64
+	// Expiry is the time to expiry in years
+	Expiry float64
+	// Vol is the annualized volatility as a fraction, 0.5 for 50%
+	Vol float64
+	// Rate discounts the payoff, continuously compounded. Deribit prices
+	// its options off the underlying future at a zero rate.
+	Rate float64
+}
+
+// Greeks are the sensitivities of the option's price. Vega and Rho are per
+// percentage point, Theta per calendar day, like Deribit reports them.
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+}
+
+// normCDF is the standard normal cumulative distribution
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF is the standard normal density
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// d1d2 are the Black-76 terms; callers handle zero volatility or time
+func (p Params) d1d2() (float64, float64) {
+	stdDev := p.Vol * math.Sqrt(p.Expiry)
+	d1 := (math.Log(p.Forward/p.Strike) + stdDev*stdDev/2) / stdDev
+	return d1, d1 - stdDev
+}
+
+func (p Params) discount() float64 {
+	return math.Exp(-p.Rate * p.Expiry)
+}
+
+// Intrinsic is the discounted payoff if the forward stayed where it is
+func (p Params) Intrinsic() float64 {
+	if p.Call {
+		return p.discount() * math.Max(p.Forward-p.Strike, 0)
+	}
+	return p.discount() * math.Max(p.Strike-p.Forward, 0)
+}
+
+// Price is the Black-76 value of the option. At expiry or zero volatility
+// it is the intrinsic value.
+func Price(p Params) float64 {
+	if p.Expiry <= 0 || p.Vol <= 0 {
+		return p.Intrinsic()
+	}
+	// Far from the money the difference can round below zero
+	d1, d2 := p.d1d2()
+	if p.Call {
+		return p.discount() * math.Max(0, p.Forward*normCDF(d1)-p.Strike*normCDF(d2))
+	}
+	return p.discount() * math.Max(0, p.Strike*normCDF(-d2)-p.Forward*normCDF(-d1))
+}
+
+// vega is the change in price for a change of 1.0 (100 points) in
+// volatility, the derivative the solver uses
+func vega(p Params) float64 {
+	if p.Expiry <= 0 || p.Vol <= 0 {
+		return 0
+	}
+	d1, _ := p.d1d2()
+	return p.discount() * p.Forward * normPDF(d1) * math.Sqrt(p.Expiry)
+}
+
+// ComputeGreeks returns the option's greeks. At expiry or zero volatility
+// delta is the payoff's and everything else zero.
+func ComputeGreeks(p Params) Greeks {
+	if p.Expiry <= 0 || p.Vol <= 0 {
+		var greeks Greeks
+		switch {
+		case p.Call && p.Forward > p.Strike:
+			greeks.Delta = p.discount()
+		case !p.Call && p.Forward < p.Strike:
+			greeks.Delta = -p.discount()
+		}
+		return greeks
+	}
+
+	d1, _ := p.d1d2()
+	discount := p.discount()
+	sqrtT := math.Sqrt(p.Expiry)
+	price := Price(p)
+
+	greeks := Greeks{
+		Gamma: discount * normPDF(d1) / (p.Forward * p.Vol * sqrtT),
+		Vega:  vega(p) / 100,
+		// Decay of the time value plus the carry of the discounting
+		Theta: (-discount*p.Forward*normPDF(d1)*p.Vol/(2*sqrtT) + p.Rate*price) / daysPerYear,
+		// On a futures option the rate only moves the discounting
+		Rho: -p.Expiry * price / 100,
+	}
+	if p.Call {
+		greeks.Delta = discount * normCDF(d1)
+	} else {
+		greeks.Delta = -discount * normCDF(-d1)
+	}
+	return greeks
+}
@@ -0,0 +1,65 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceATM(t *testing.T) {
+	// At the money with no rate: F * (2N(vol*sqrt(T)/2) - 1)
+	p := Params{Call: true, Forward: 100, Strike: 100, Expiry: 1, Vol: 0.2}
+	want := 100 * (2*normCDF(0.1) - 1)
+	if got := Price(p); math.Abs(got-want) > 1e-12 {
+		t.Errorf("call = %v, want %v", got, want)
+	}
+	p.Call = false
+	if got := Price(p); math.Abs(got-want) > 1e-12 {
+		t.Errorf("put = %v, want %v", got, want)
+	}
+}
+
+func TestGreeksFiniteDifferences(t *testing.T) {
+	// central difference of price as f moves params by h
+	diff := func(p Params, h float64, move func(p *Params, by float64)) float64 {
+		up, down := p, p
+		move(&up, h)
+		move(&down, -h)
+		return (Price(up) - Price(down)) / (2 * h)
+	}
+
+	for _, p := range []Params{
+		{Call: true, Forward: 100, Strike: 100, Expiry: 1, Vol: 0.2},
+		{Call: false, Forward: 100, Strike: 100, Expiry: 1, Vol: 0.2},
+		{Call: true, Forward: 60_000, Strike: 70_000, Expiry: 0.1, Vol: 0.6, Rate: 0.05},
+		{Call: false, Forward: 60_000, Strike: 45_000, Expiry: 0.5, Vol: 0.8, Rate: 0.03},
+		{Call: true, Forward: 3_000, Strike: 2_000, Expiry: 2, Vol: 0.7},
+		{Call: false, Forward: 3_000, Strike: 3_100, Expiry: 7.0 / daysPerYear, Vol: 0.5},
+	} {
+		greeks := ComputeGreeks(p)
+		h := p.Forward * 1e-4
+
+		delta := diff(p, h, func(p *Params, by float64) { p.Forward += by })
+		up, down := p, p
+		up.Forward += h
+		down.Forward -= h
+		gamma := (Price(up) - 2*Price(p) + Price(down)) / (h * h)
+		vega := diff(p, 1e-5, func(p *Params, by float64) { p.Vol += by }) / 100
+		theta := -diff(p, 1e-6, func(p *Params, by float64) { p.Expiry += by }) / daysPerYear
+		rho := diff(p, 1e-6, func(p *Params, by float64) { p.Rate += by }) / 100
+
+		for _, check := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"delta", greeks.Delta, delta},
+			{"gamma", greeks.Gamma, gamma},
+			{"vega", greeks.Vega, vega},
+			{"theta", greeks.Theta, theta},
+			{"rho", greeks.Rho, rho},
+		} {
+			if math.Abs(check.got-check.want) > 1e-4*math.Abs(check.want)+1e-10 {
+				t.Errorf("%+v: %s = %v, finite difference %v", p, check.name, check.got, check.want)
+			}
+		}
+	}
+}
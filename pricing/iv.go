@@ -0,0 +1,103 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// minVol and maxVol bracket the solver, 0.01% to 2000%
+	minVol = 1e-4
+	maxVol = 20.0
+	// volTolerance is how close to the root the solver stops
+	volTolerance  = 1e-8
+	maxIterations = 100
+)
+
+var (
+	ErrExpired = errors.New("option expired")
+	// ErrBelowIntrinsic and ErrAboveMax are prices no volatility explains
+	ErrBelowIntrinsic = errors.New("price below intrinsic value")
+	ErrAboveMax       = errors.New("price above the option's maximum value")
+)
+
+// ImpliedVol solves for the volatility at which the option is worth price.
+// p.Vol is ignored.
+//
+// In the money options are solved as the out of the money option of the
+// other type with the same strike, through put-call parity: their price is
+// all time value, which keeps the solver precise deep in the money. The
+// search is Newton's method kept inside a bracket that shrinks every step,
+// falling back to bisection where vega is too small for Newton, as it is
+// far from the money and near expiry.
+func ImpliedVol(price float64, p Params) (float64, error) {
+	if p.Expiry <= 0 {
+		return 0, ErrExpired
+	}
+
+	discount := p.discount()
+	if inTheMoney := p.Call == (p.Forward > p.Strike); inTheMoney {
+		// Parity: call - put = discount * (forward - strike)
+		parity := discount * (p.Forward - p.Strike)
+		if p.Call {
+			price -= parity
+		} else {
+			price += parity
+		}
+		p.Call = !p.Call
+	}
+
+	// An out of the money option is worth between nothing and the
+	// discounted strike (put) or forward (call)
+	upper := discount * p.Forward
+	if !p.Call {
+		upper = discount * p.Strike
+	}
+	// Prices at intrinsic value lose a few bits to the parity above
+	if price < -upper*1e-12 {
+		return 0, ErrBelowIntrinsic
+	}
+	if price >= upper {
+		return 0, ErrAboveMax
+	}
+	if price <= 0 {
+		return 0, nil
+	}
+
+	low, high := minVol, maxVol
+	p.Vol = low
+	if Price(p) > price {
+		// Worth more than at the smallest volatility considered
+		return minVol, nil
+	}
+	p.Vol = high
+	if Price(p) < price {
+		return maxVol, nil
+	}
+
+	// Brenner-Subrahmanyam's at the money approximation as the first guess
+	vol := math.Sqrt(2*math.Pi/p.Expiry) * price / (discount * p.Forward)
+	vol = math.Max(low, math.Min(high, vol))
+	for range maxIterations {
+		p.Vol = vol
+		diff := Price(p) - price
+		if diff > 0 {
+			high = vol
+		} else {
+			low = vol
+		}
+
+		next := (low + high) / 2
+		if v := vega(p); v > 0 {
+			// Newton's step, unless it leaves the bracket
+			if step := vol - diff/v; step > low && step < high {
+				next = step
+			}
+		}
+		if math.Abs(next-vol) < volTolerance || high-low < volTolerance {
+			return next, nil
+		}
+		vol = next
+	}
+	return vol, nil
+}
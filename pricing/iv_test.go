@@ -0,0 +1,55 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestImpliedVolRoundTrip(t *testing.T) {
+	const forward = 50_000.0
+	for _, moneyness := range []float64{0.3, 0.5, 0.8, 0.95, 1, 1.05, 1.25, 2, 3} {
+		for _, expiry := range []float64{1e-5, 1.0 / daysPerYear, 7.0 / daysPerYear, 0.25, 1, 3} {
+			for _, vol := range []float64{0.05, 0.2, 0.6, 1.5} {
+				for _, call := range []bool{true, false} {
+					p := Params{Call: call, Forward: forward, Strike: forward * moneyness, Expiry: expiry, Vol: vol}
+					price := Price(p)
+
+					// Past a point the time value rounds away and no
+					// volatility can be told from another
+					if price-p.Intrinsic() < forward*1e-10 {
+						continue
+					}
+
+					got, err := ImpliedVol(price, p)
+					if err != nil {
+						t.Errorf("ImpliedVol(%+v) failed: %v", p, err)
+						continue
+					}
+					if math.Abs(got-vol) > 1e-6*vol {
+						t.Errorf("ImpliedVol(%+v) = %v", p, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestImpliedVolErrors(t *testing.T) {
+	p := Params{Call: true, Forward: 100, Strike: 80, Expiry: 0.5}
+	tests := []struct {
+		name  string
+		price float64
+		p     Params
+		err   error
+	}{
+		{"expired", 25, Params{Call: true, Forward: 100, Strike: 80}, ErrExpired},
+		{"below intrinsic", 19, p, ErrBelowIntrinsic},
+		{"above forward", 101, p, ErrAboveMax},
+	}
+	for _, test := range tests {
+		if _, err := ImpliedVol(test.price, test.p); !errors.Is(err, test.err) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+}
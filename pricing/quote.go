@@ -0,0 +1,100 @@
+package pricing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+var ErrNotOption = errors.New("instrument is not an option")
+
+// Quote is an option's market as implied volatilities, fractions like
+// Params.Vol. An IV is zero when its price is missing or no volatility
+// explains it.
+type Quote struct {
+	BidIV  float64
+	AskIV  float64
+	MarkIV float64
+	// Greeks are at the mark IV
+	Greeks Greeks
+}
+
+// YearsUntil is the time to expiry in years
+func YearsUntil(expiry, now time.Time) float64 {
+	return expiry.Sub(now).Hours() / 24 / daysPerYear
+}
+
+// ForInstrument returns the params of an option instrument priced off
+// forward, the price of its underlying in USD. Vol is left zero.
+func ForInstrument(spec *models.Instrument, forward float64, now time.Time) (Params, error) {
+	if spec == nil || spec.Kind != "option" {
+		return Params{}, ErrNotOption
+	}
+	return Params{
+		Call:    spec.OptionType == "call",
+		Forward: forward,
+		Strike:  spec.Strike,
+		Expiry:  YearsUntil(spec.Expiry(), now),
+	}, nil
+}
+
+// ToUSD converts a price as the instrument is quoted to USD. Inverse
+// options are quoted in the coin, as a fraction of the underlying.
+func ToUSD(spec *models.Instrument, price, forward float64) float64 {
+	if spec.Inverse() {
+		return price * forward
+	}
+	return price
+}
+
+// FromUSD converts a USD price to how the instrument is quoted
+func FromUSD(spec *models.Instrument, usd, forward float64) float64 {
+	if spec.Inverse() {
+		return usd / forward
+	}
+	return usd
+}
+
+// QuotePrices solves the IVs of a bid, ask and mark as the instrument
+// quotes them
+func QuotePrices(spec *models.Instrument, bid, ask, mark, forward float64, now time.Time) (Quote, error) {
+	params, err := ForInstrument(spec, forward, now)
+	if err != nil {
+		return Quote{}, err
+	}
+	if params.Expiry <= 0 {
+		return Quote{}, ErrExpired
+	}
+
+	iv := func(price float64) float64 {
+		if price <= 0 {
+			return 0
+		}
+		vol, err := ImpliedVol(ToUSD(spec, price, forward), params)
+		if err != nil {
+			return 0
+		}
+		return vol
+	}
+
+	quote := Quote{BidIV: iv(bid), AskIV: iv(ask), MarkIV: iv(mark)}
+	if quote.MarkIV > 0 {
+		params.Vol = quote.MarkIV
+		quote.Greeks = ComputeGreeks(params)
+	}
+	return quote, nil
+}
+
+// QuoteBook solves the IVs of the best bid and ask of book and its mark
+// price, which is zero in books built from incremental updates
+func QuoteBook(book *models.OrderBookResult, spec *models.Instrument, forward float64, now time.Time) (Quote, error) {
+	bid, ask := 0.0, 0.0
+	if len(book.Bids) > 0 {
+		bid = book.Bids[0][0]
+	}
+	if len(book.Asks) > 0 {
+		ask = book.Asks[0][0]
+	}
+	return QuotePrices(spec, bid, ask, book.MarkPrice, forward, now)
+}
@@ -0,0 +1,161 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/adityanagar10/trader/models"
+)
+
+// smileMinDelta leaves out the far wings, whose marks are extrapolated
+// and would dwarf the rest of the smile
+const smileMinDelta = 0.02
+
+// SmilePoint is the mark IV of the out of the money option at a strike
+type SmilePoint struct {
+	Strike float64
+	IV     float64
+	// Moneyness is ln(strike / forward)
+	Moneyness float64
+	// Delta is the call delta at the IV
+	Delta float64
+}
+
+// Smile is the implied volatility across the strikes of one expiry
+type Smile struct {
+	Expiry time.Time
+	// Forward is implied from the call and put marks through parity
+	Forward float64
+	// Points are sorted by strike
+	Points []SmilePoint
+}
+
+// strikeMarks are the call and put marks at one strike
+type strikeMarks struct {
+	spec      *models.Instrument
+	call, put *models.OptionMark
+}
+
+// impliedForward solves put-call parity for the forward. With no
+// discounting call - put = forward - strike in USD, which inverse options
+// quote divided by the forward.
+func impliedForward(spec *models.Instrument, strike, call, put float64) float64 {
+	if !spec.Inverse() {
+		return strike + call - put
+	}
+	if call-put >= 1 {
+		return 0
+	}
+	return strike / (1 - (call - put))
+}
+
+// BuildSmiles groups the marks of a price index's options into one smile
+// per expiry, nearest first. lookup returns an instrument by name, nil
+// when unknown; marks of unknown or expired options are skipped.
+func BuildSmiles(marks []models.OptionMark, lookup func(string) *models.Instrument, now time.Time) []Smile {
+	expiries := make(map[int64]map[float64]*strikeMarks)
+	for i := range marks {
+		spec := lookup(marks[i].InstrumentName)
+		if spec == nil || spec.Kind != "option" || !spec.Expiry().After(now) {
+			continue
+		}
+		strikes, ok := expiries[spec.ExpirationTimestamp]
+		if !ok {
+			strikes = make(map[float64]*strikeMarks)
+			expiries[spec.ExpirationTimestamp] = strikes
+		}
+		legs, ok := strikes[spec.Strike]
+		if !ok {
+			legs = &strikeMarks{spec: spec}
+			strikes[spec.Strike] = legs
+		}
+		if spec.OptionType == "call" {
+			legs.call = &marks[i]
+		} else {
+			legs.put = &marks[i]
+		}
+	}
+
+	var smiles []Smile
+	for expiry, strikes := range expiries {
+		if smile, ok := buildSmile(time.UnixMilli(expiry), strikes, now); ok {
+			smiles = append(smiles, smile)
+		}
+	}
+	sort.Slice(smiles, func(i, j int) bool {
+		return smiles[i].Expiry.Before(smiles[j].Expiry)
+	})
+	return smiles
+}
+
+func buildSmile(expiry time.Time, strikes map[float64]*strikeMarks, now time.Time) (Smile, bool) {
+	// The strike where call and put are worth the most alike is the
+	// closest to the money, parity is most precise there
+	smile := Smile{Expiry: expiry}
+	closest := math.Inf(1)
+	for strike, legs := range strikes {
+		if legs.call == nil || legs.put == nil {
+			continue
+		}
+		if diff := math.Abs(legs.call.MarkPrice - legs.put.MarkPrice); diff < closest {
+			closest = diff
+			smile.Forward = impliedForward(legs.spec, strike, legs.call.MarkPrice, legs.put.MarkPrice)
+		}
+	}
+	if smile.Forward <= 0 {
+		return Smile{}, false
+	}
+
+	years := YearsUntil(expiry, now)
+	for strike, legs := range strikes {
+		mark := legs.put
+		if strike >= smile.Forward || mark == nil {
+			mark = legs.call
+		}
+		if mark == nil || mark.IV <= 0 {
+			mark = legs.put
+		}
+		if mark == nil || mark.IV <= 0 {
+			continue
+		}
+
+		delta := ComputeGreeks(Params{Call: true, Forward: smile.Forward, Strike: strike, Expiry: years, Vol: mark.IV}).Delta
+		if delta < smileMinDelta || delta > 1-smileMinDelta {
+			continue
+		}
+		smile.Points = append(smile.Points, SmilePoint{
+			Strike:    strike,
+			IV:        mark.IV,
+			Moneyness: math.Log(strike / smile.Forward),
+			Delta:     delta,
+		})
+	}
+	if len(smile.Points) == 0 {
+		return Smile{}, false
+	}
+	sort.Slice(smile.Points, func(i, j int) bool {
+		return smile.Points[i].Strike < smile.Points[j].Strike
+	})
+	return smile, true
+}
+
+// ATMVol interpolates the smile's IV at the forward, or takes the nearest
+// strike when the forward is outside the strikes
+func (s Smile) ATMVol() float64 {
+	points := s.Points
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Strike >= s.Forward
+	})
+	switch {
+	case len(points) == 0:
+		return 0
+	case i == 0:
+		return points[0].IV
+	case i == len(points):
+		return points[i-1].IV
+	}
+	below, above := points[i-1], points[i]
+	t := (s.Forward - below.Strike) / (above.Strike - below.Strike)
+	return below.IV + t*(above.IV-below.IV)
+}
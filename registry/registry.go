@@ -0,0 +1,363 @@
+// Package registry keeps the list of tradable instruments, loaded from
+// Deribit and cached on disk so the app can start offline.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adityanagar10/trader/marketdata"
+	"github.com/adityanagar10/trader/models"
+	"github.com/adityanagar10/trader/settings"
+)
+
+const (
+	// cacheFile is the settings file the registry is cached in
+	cacheFile = "instruments.json"
+	// retryInterval is the wait before loading the instrument lists that
+	// failed again
+	retryInterval = 30 * time.Second
+)
+
+// Kinds are the instrument kinds loaded for every currency. Perpetuals
+// are futures with a "perpetual" settlement period.
+var Kinds = []string{"future", "option", "spot", "future_combo", "option_combo"}
+
+// Source is where instruments are loaded from, implemented by the client
+type Source interface {
+	FetchCurrencies(ctx context.Context) ([]string, error)
+	FetchInstruments(ctx context.Context, currency, kind string) ([]models.Instrument, error)
+}
+
+// instrumentList is one list loaded from the source, the instruments of a
+// currency and kind
+type instrumentList struct {
+	Currency string `json:"currency"`
+	Kind     string `json:"kind"`
+}
+
+// cachedList is a list and its instruments in the cache
+type cachedList struct {
+	instrumentList
+	Instruments []models.Instrument `json:"instruments"`
+}
+
+// cache is the on-disk format
+type cache struct {
+	UpdatedAt time.Time    `json:"updated_at"`
+	Lists     []cachedList `json:"lists"`
+}
+
+// Registry holds every known instrument. Each list of a currency and kind
+// is replaced as it loads, one that fails keeps what it had and is loaded
+// again soon after; the instruments themselves are never modified, so
+// callers can keep the pointers they get.
+type Registry struct {
+	source Source
+	// store, when set, receives every instrument's specification
+	store *marketdata.Store
+
+	mu          sync.RWMutex
+	lists       map[instrumentList][]models.Instrument
+	instruments map[string]*models.Instrument
+	sorted      []*models.Instrument
+	// updatedAt is when every list last loaded
+	updatedAt time.Time
+	version   uint64
+	// failed are the lists the last load could not get, retried by the
+	// refresh loop
+	failed []instrumentList
+
+	refresh chan struct{}
+	done    chan struct{}
+}
+
+func NewRegistry(source Source, store *marketdata.Store) *Registry {
+	return &Registry{
+		source:      source,
+		store:       store,
+		lists:       make(map[instrumentList][]models.Instrument),
+		instruments: make(map[string]*models.Instrument),
+		refresh:     make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+// LoadCache fills the registry from the disk cache of the last refresh
+func (r *Registry) LoadCache() error {
+	var cached cache
+	if err := settings.Load(cacheFile, &cached); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	lists := make(map[instrumentList][]models.Instrument, len(cached.Lists))
+	for _, cached := range cached.Lists {
+		lists[cached.instrumentList] = cached.Instruments
+	}
+	r.replace(lists, nil, cached.UpdatedAt)
+	return nil
+}
+
+// Refresh loads every currency and kind from the source, see load
+func (r *Registry) Refresh(ctx context.Context) error {
+	currencies, err := r.source.FetchCurrencies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load currencies: %v", err)
+	}
+
+	var lists []instrumentList
+	for _, currency := range currencies {
+		for _, kind := range Kinds {
+			lists = append(lists, instrumentList{Currency: currency, Kind: kind})
+		}
+	}
+	return r.load(ctx, lists, true)
+}
+
+// retry loads the lists the last load could not get, or everything when
+// it failed before getting the currencies
+func (r *Registry) retry(ctx context.Context) error {
+	r.mu.RLock()
+	failed := r.failed
+	r.mu.RUnlock()
+
+	if len(failed) == 0 {
+		return r.Refresh(ctx)
+	}
+	return r.load(ctx, failed, false)
+}
+
+// load fetches lists from the source and puts every one that loaded in
+// the registry, the others keep their instruments. When lists are all of
+// them, any other list is dropped. The registry is cached on disk either
+// way, but only counts as updated once nothing failed.
+func (r *Registry) load(ctx context.Context, lists []instrumentList, all bool) error {
+	loaded := make(map[instrumentList][]models.Instrument, len(lists))
+	var failed []instrumentList
+	var firstErr error
+	for _, list := range lists {
+		instruments, err := r.source.FetchInstruments(ctx, list.Currency, list.Kind)
+		if err != nil {
+			failed = append(failed, list)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to load %s %s instruments: %v", list.Currency, list.Kind, err)
+			}
+			continue
+		}
+		loaded[list] = instruments
+	}
+
+	r.mu.RLock()
+	updatedAt := r.updatedAt
+	r.mu.RUnlock()
+	if len(failed) == 0 {
+		updatedAt = time.Now()
+	}
+	if all {
+		r.mu.Lock()
+		for list := range r.lists {
+			if !slices.Contains(lists, list) {
+				delete(r.lists, list)
+			}
+		}
+		r.mu.Unlock()
+	}
+	r.replace(loaded, failed, updatedAt)
+
+	r.mu.RLock()
+	saved := cache{UpdatedAt: updatedAt}
+	for list, instruments := range r.lists {
+		saved.Lists = append(saved.Lists, cachedList{instrumentList: list, Instruments: instruments})
+	}
+	r.mu.RUnlock()
+	if err := settings.Save(cacheFile, saved); err != nil {
+		log.Printf("Failed to cache instruments: %v", err)
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d instrument lists: %w", len(failed), len(lists), firstErr)
+	}
+	return nil
+}
+
+// replace puts loaded lists in the registry in place of what they held,
+// records failed for a retry and publishes every specification
+func (r *Registry) replace(loaded map[instrumentList][]models.Instrument, failed []instrumentList, updatedAt time.Time) {
+	r.mu.Lock()
+	for list, instruments := range loaded {
+		r.lists[list] = instruments
+	}
+
+	instruments := make(map[string]*models.Instrument)
+	var sorted []*models.Instrument
+	for _, listed := range r.lists {
+		for i := range listed {
+			// Currencies can share instruments, keep one of each
+			if _, ok := instruments[listed[i].InstrumentName]; ok {
+				continue
+			}
+			instruments[listed[i].InstrumentName] = &listed[i]
+			sorted = append(sorted, &listed[i])
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return lessInstrument(sorted[i], sorted[j])
+	})
+
+	r.instruments = instruments
+	r.sorted = sorted
+	r.updatedAt = updatedAt
+	r.failed = failed
+	r.version++
+	r.mu.Unlock()
+
+	if r.store != nil {
+		for _, instrument := range sorted {
+			r.store.Publish(instrument.InstrumentName, marketdata.KindInstrument, instrument)
+		}
+	}
+}
+
+// kindOrder sorts perpetuals first, then dated futures, spot, options and combos
+func kindOrder(instrument *models.Instrument) int {
+	switch {
+	case instrument.Perpetual():
+		return 0
+	case instrument.Kind == "future":
+		return 1
+	case instrument.Kind == "spot":
+		return 2
+	case instrument.Kind == "option":
+		return 3
+	}
+	return 4
+}
+
+func lessInstrument(a, b *models.Instrument) bool {
+	if a.BaseCurrency != b.BaseCurrency {
+		return a.BaseCurrency < b.BaseCurrency
+	}
+	if kindOrder(a) != kindOrder(b) {
+		return kindOrder(a) < kindOrder(b)
+	}
+	if a.ExpirationTimestamp != b.ExpirationTimestamp {
+		return a.ExpirationTimestamp < b.ExpirationTimestamp
+	}
+	if a.Strike != b.Strike {
+		return a.Strike < b.Strike
+	}
+	return a.InstrumentName < b.InstrumentName
+}
+
+// Start refreshes the registry every interval, and whenever RefreshNow is
+// called, until Stop. Lists that fail to load are retried after
+// retryInterval.
+func (r *Registry) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var retry <-chan time.Time
+		for {
+			var err error
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				err = r.Refresh(context.Background())
+			case <-r.refresh:
+				err = r.Refresh(context.Background())
+			case <-retry:
+				err = r.retry(context.Background())
+			}
+
+			retry = nil
+			if err != nil {
+				log.Printf("Instrument refresh failed, retrying in %v: %v", retryInterval, err)
+				retry = time.After(retryInterval)
+				continue
+			}
+			log.Printf("Loaded %d instruments", r.Len())
+		}
+	}()
+}
+
+// RefreshNow asks the refresh loop for a refresh without waiting for it
+func (r *Registry) RefreshNow() {
+	select {
+	case r.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Registry) Stop() {
+	close(r.done)
+}
+
+// Get returns an instrument by name, nil if it is unknown
+func (r *Registry) Get(name string) *models.Instrument {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.instruments[name]
+}
+
+// All returns every instrument, grouped by currency, then perpetuals,
+// futures, spot, options and combos, each by expiry and strike
+func (r *Registry) All() []*models.Instrument {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sorted
+}
+
+// Filter returns the instruments for which keep returns true, in the
+// order of All
+func (r *Registry) Filter(keep func(*models.Instrument) bool) []*models.Instrument {
+	var result []*models.Instrument
+	for _, instrument := range r.All() {
+		if keep(instrument) {
+			result = append(result, instrument)
+		}
+	}
+	return result
+}
+
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.sorted)
+}
+
+// Version changes on every refresh, so the UI can tell when to rebuild
+// lists derived from the registry
+func (r *Registry) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// UpdatedAt is when the instruments were loaded from Deribit
+func (r *Registry) UpdatedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.updatedAt
+}
+
+// Symbol is the short name of an instrument shown in window titles:
+// perpetuals by their pair, as in btcusd, anything else by its name
+func (r *Registry) Symbol(name string) string {
+	instrument := r.Get(name)
+	if instrument == nil || !instrument.Perpetual() {
+		return name
+	}
+	return strings.ToLower(instrument.BaseCurrency + instrument.CounterCurrency)
+}
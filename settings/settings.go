@@ -0,0 +1,65 @@
+// Package settings persists small JSON files, such as caches and user
+// preferences, in the user's config directory.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// appDir is the directory under the user's config directory
+const appDir = "trader"
+
+// Path returns the location of a settings file, creating its directory
+func Path(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no config directory: %v", err)
+	}
+	dir = filepath.Join(dir, appDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Load decodes a settings file into v. A missing file is reported with an
+// error satisfying errors.Is(err, os.ErrNotExist).
+func Load(name string, v interface{}) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
+
+// Save writes v to a settings file. The file is replaced in one step, so
+// a crash never leaves half of it behind.
+func Save(name string, v interface{}) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}